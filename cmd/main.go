@@ -3,21 +3,35 @@ package main
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"scraper/config"
 	"scraper/handlers"
+	"scraper/jobs"
+	"scraper/storage"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// Periodically remove expired scrape results from the storage.
+	storage.StartJanitor(time.Duration(config.GetStorageJanitorInterval()) * time.Second)
+
+	// Start workers which execute asynchronous scrape jobs.
+	jobs.Start(config.GetJobWorkerCount(), config.GetJobQueueSize())
+
 	router := gin.Default()
 
 	router.Use(cors.Default())
 
 	router.GET("/scrape", handlers.ScrapeHandler)
+	router.GET("/scrape/:id/summary", handlers.SummaryHandler)
+	router.GET("/scrape/:id/events", handlers.EventsHandler)
 	router.GET("/scrape/:id/:page", handlers.PageHandler)
+	router.POST("/jobs", handlers.CreateJobHandler)
+	router.GET("/jobs/:id", handlers.JobStatusHandler)
+	router.GET("/ws", handlers.WebSocketHandler)
 
 	log.Fatal(router.Run(fmt.Sprintf(":%s", config.GetAppPort())))
 }
@@ -13,6 +13,27 @@ const (
 	defaultURLCheckPageSize                  = 10
 	defaultOutgoingScrapeRequestTimeout      = 30
 	defaultOutgoingAccessibilityCheckTimeout = 10
+	defaultStorageBackend                    = "memory"
+	defaultStorageFilePath                   = "data/scraper.db"
+	defaultStorageEntryTTL                   = 3600
+	defaultStorageMaxEntries                 = 1000
+	defaultStorageMaxBytes                   = 256 * 1024 * 1024
+	defaultStorageJanitorInterval            = 60
+	defaultRequestIDFormat                   = "random"
+	defaultJobWorkerCount                    = 4
+	defaultJobQueueSize                      = 100
+	defaultJobRetention                      = 3600
+	defaultBackgroundCheckConcurrency        = 2
+	defaultURLCheckWorkerPoolSize            = 20
+	defaultURLCheckHostRateLimit             = 5
+	defaultURLCheckHostBurst                 = 5
+	defaultURLCheckHostMaxInFlight           = 2
+	defaultURLCheckMaxRedirects              = 10
+	defaultURLCheckMaxRetries                = 2
+	defaultURLCheckRetryBaseDelay            = 500
+	defaultURLCheckRetryMaxDelay             = 10000
+	defaultURLNormalizeSortQuery             = false
+	defaultWebSocketAllowedOrigins           = ""
 )
 
 // Configuration variables initialized once
@@ -21,6 +42,27 @@ var (
 	urlCheckPageSize                  int
 	outgoingScrapeRequestTimeout      int
 	outgoingAccessibilityCheckTimeout int
+	storageBackend                    string
+	storageFilePath                   string
+	storageEntryTTL                   int
+	storageMaxEntries                 int
+	storageMaxBytes                   int
+	storageJanitorInterval            int
+	requestIDFormat                   string
+	jobWorkerCount                    int
+	jobQueueSize                      int
+	jobRetention                      int
+	backgroundCheckConcurrency        int
+	urlCheckWorkerPoolSize            int
+	urlCheckHostRateLimit             int
+	urlCheckHostBurst                 int
+	urlCheckHostMaxInFlight           int
+	urlCheckMaxRedirects              int
+	urlCheckMaxRetries                int
+	urlCheckRetryBaseDelay            int
+	urlCheckRetryMaxDelay             int
+	urlNormalizeSortQuery             bool
+	webSocketAllowedOrigins           string
 )
 
 func init() {
@@ -32,6 +74,37 @@ func init() {
 		defaultOutgoingScrapeRequestTimeout)
 	outgoingAccessibilityCheckTimeout = parseEnvAsInt("OUT_GOING_URL_ACCESSIBILITY_CHECK_TIMEOUT",
 		defaultOutgoingAccessibilityCheckTimeout)
+
+	storageBackend = getEnv("STORAGE_BACKEND", defaultStorageBackend)
+	storageFilePath = getEnv("STORAGE_FILE_PATH", defaultStorageFilePath)
+	storageEntryTTL = parseEnvAsInt("STORAGE_ENTRY_TTL", defaultStorageEntryTTL)
+	storageMaxEntries = parseEnvAsInt("STORAGE_MAX_ENTRIES", defaultStorageMaxEntries)
+	storageMaxBytes = parseEnvAsInt("STORAGE_MAX_BYTES", defaultStorageMaxBytes)
+	storageJanitorInterval = parseEnvAsInt("STORAGE_JANITOR_INTERVAL", defaultStorageJanitorInterval)
+	requestIDFormat = getEnv("REQUEST_ID_FORMAT", defaultRequestIDFormat)
+
+	jobWorkerCount = parseEnvAsInt("JOB_WORKER_COUNT", defaultJobWorkerCount)
+	jobQueueSize = parseEnvAsInt("JOB_QUEUE_SIZE", defaultJobQueueSize)
+	jobRetention = parseEnvAsInt("JOB_RETENTION", defaultJobRetention)
+	backgroundCheckConcurrency = parseEnvAsInt("BACKGROUND_URL_CHECK_CONCURRENCY",
+		defaultBackgroundCheckConcurrency)
+
+	urlCheckWorkerPoolSize = parseEnvAsInt("URL_CHECK_WORKER_POOL_SIZE",
+		defaultURLCheckWorkerPoolSize)
+	urlCheckHostRateLimit = parseEnvAsInt("URL_CHECK_HOST_RATE_LIMIT", defaultURLCheckHostRateLimit)
+	urlCheckHostBurst = parseEnvAsInt("URL_CHECK_HOST_BURST", defaultURLCheckHostBurst)
+	urlCheckHostMaxInFlight = parseEnvAsInt("URL_CHECK_HOST_MAX_IN_FLIGHT",
+		defaultURLCheckHostMaxInFlight)
+	urlCheckMaxRedirects = parseEnvAsInt("URL_CHECK_MAX_REDIRECTS", defaultURLCheckMaxRedirects)
+	urlCheckMaxRetries = parseEnvAsInt("URL_CHECK_MAX_RETRIES", defaultURLCheckMaxRetries)
+	urlCheckRetryBaseDelay = parseEnvAsInt("URL_CHECK_RETRY_BASE_DELAY_MS",
+		defaultURLCheckRetryBaseDelay)
+	urlCheckRetryMaxDelay = parseEnvAsInt("URL_CHECK_RETRY_MAX_DELAY_MS",
+		defaultURLCheckRetryMaxDelay)
+
+	urlNormalizeSortQuery = parseEnvAsBool("URL_NORMALIZE_SORT_QUERY", defaultURLNormalizeSortQuery)
+
+	webSocketAllowedOrigins = getEnv("WEBSOCKET_ALLOWED_ORIGINS", defaultWebSocketAllowedOrigins)
 }
 
 // Helper function to get environment variable or return a default
@@ -57,6 +130,20 @@ func parseEnvAsInt(key string, defaultValue int) int {
 	return parsedValue
 }
 
+// Helper function to parse environment variable as bool or return a default
+func parseEnvAsBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsedValue, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Invalid value for %s: %v", key, err))
+		return defaultValue
+	}
+	return parsedValue
+}
+
 // Exported getter functions
 func GetAppPort() string {
 	return appPort
@@ -73,3 +160,87 @@ func GetOutgoingScrapeRequestTimeout() int {
 func GetOutgoingAccessibilityCheckTimeout() int {
 	return outgoingAccessibilityCheckTimeout
 }
+
+func GetStorageBackend() string {
+	return storageBackend
+}
+
+func GetStorageFilePath() string {
+	return storageFilePath
+}
+
+func GetStorageEntryTTL() int {
+	return storageEntryTTL
+}
+
+func GetStorageMaxEntries() int {
+	return storageMaxEntries
+}
+
+func GetStorageMaxBytes() int64 {
+	return int64(storageMaxBytes)
+}
+
+func GetStorageJanitorInterval() int {
+	return storageJanitorInterval
+}
+
+func GetRequestIDFormat() string {
+	return requestIDFormat
+}
+
+func GetJobWorkerCount() int {
+	return jobWorkerCount
+}
+
+func GetJobQueueSize() int {
+	return jobQueueSize
+}
+
+func GetJobRetention() int {
+	return jobRetention
+}
+
+func GetBackgroundCheckConcurrency() int {
+	return backgroundCheckConcurrency
+}
+
+func GetURLCheckWorkerPoolSize() int {
+	return urlCheckWorkerPoolSize
+}
+
+func GetURLCheckHostRateLimit() float64 {
+	return float64(urlCheckHostRateLimit)
+}
+
+func GetURLCheckHostBurst() int {
+	return urlCheckHostBurst
+}
+
+func GetURLCheckHostMaxInFlight() int {
+	return urlCheckHostMaxInFlight
+}
+
+func GetURLCheckMaxRedirects() int {
+	return urlCheckMaxRedirects
+}
+
+func GetURLCheckMaxRetries() int {
+	return urlCheckMaxRetries
+}
+
+func GetURLCheckRetryBaseDelay() int {
+	return urlCheckRetryBaseDelay
+}
+
+func GetURLCheckRetryMaxDelay() int {
+	return urlCheckRetryMaxDelay
+}
+
+func GetURLNormalizeSortQuery() bool {
+	return urlNormalizeSortQuery
+}
+
+func GetWebSocketAllowedOrigins() string {
+	return webSocketAllowedOrigins
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"scraper/jobs"
+	"scraper/models"
+	"scraper/services"
+	"scraper/storage"
+	"scraper/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Server-Sent Event names.
+const (
+	urlStatusEvent = "url"
+	summaryEvent   = "summary"
+)
+
+// This handles the request to stream URL statuses of a scraped page as Server-Sent Events.
+// Already checked URLs are sent first, then the rest of the URLs are checked in the background
+// and sent as they are checked. Stream ends with a summary event.
+// Only URLs of the requested resource types are streamed and checked.
+func EventsHandler(context *gin.Context) {
+	requestID := context.Param("id")
+	resourceTypes, err := parseResourceTypes(context.Query("types"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, utils.BuildErrorResponse(err.Error()))
+		return
+	}
+
+	pageInfo, exists := retrievePageInfo(context, requestID)
+	if !exists {
+		return
+	}
+
+	// Subscribe before looking at URL statuses, so no status checked in between is missed.
+	events, unsubscribe := jobs.Subscribe(requestID, len(pageInfo.URLs)+1)
+	defer unsubscribe()
+
+	pageInfo, exists = retrievePageInfo(context, requestID)
+	if !exists {
+		return
+	}
+	urls := services.FilterURLsByType(pageInfo.URLs, resourceTypes)
+
+	context.Header("Cache-Control", "no-cache")
+	context.Header("Connection", "keep-alive")
+
+	sent := make(map[string]struct{}, len(urls))
+	for _, urlStatus := range urls {
+		if _, found := sent[urlStatus.URL]; !found && urlStatus.CheckedAt != nil {
+			sent[urlStatus.URL] = struct{}{}
+			context.SSEvent(urlStatusEvent, urlStatus)
+		}
+	}
+
+	_, _, pending := services.SummarizeURLStatus(urls)
+	if pending > 0 && jobs.CheckAllURLs(requestID, resourceTypes) {
+		// A check of other resource types may have been running already, so only statuses of
+		// the streamed URLs are sent.
+		streamed := indexURLs(urls)
+		context.Writer.Flush()
+		clientGone := context.Request.Context().Done()
+		for streaming := true; streaming; {
+			select {
+			case checked, open := <-events:
+				urlStatus, found := streamed[checked.URL]
+				if streaming = open; open && found {
+					urlStatus.SetCheckResult(checked)
+					context.SSEvent(urlStatusEvent, urlStatus)
+					context.Writer.Flush()
+				}
+			case <-clientGone:
+				return
+			}
+		}
+	}
+
+	// Summary is built from the storage which holds statuses of all checked URLs by now.
+	if pageInfo, exists = storage.RetrievePageInfo(requestID); exists {
+		context.SSEvent(summaryEvent, buildCheckSummary(requestID,
+			services.FilterURLsByType(pageInfo.URLs, resourceTypes)))
+		context.Writer.Flush()
+	}
+}
+
+// This is to index the URLs by address, keeping the first entry of each address.
+func indexURLs(urls []models.URLStatus) map[string]models.URLStatus {
+	index := make(map[string]models.URLStatus, len(urls))
+	for _, urlStatus := range urls {
+		if _, found := index[urlStatus.URL]; !found {
+			index[urlStatus.URL] = urlStatus
+		}
+	}
+	return index
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"scraper/jobs"
+	"scraper/models"
+	"scraper/services"
+	"scraper/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventsHandler(test_type *testing.T) {
+	checkedAt := time.Now()
+	checkedPage := &models.PageInfo{URLs: []models.URLStatus{
+		{URL: "http://example.com/ok", HTTPStatus: 200, CheckedAt: &checkedAt},
+		{URL: "http://example.com/broken", HTTPStatus: 404, CheckedAt: &checkedAt},
+	}}
+	pendingPage := &models.PageInfo{URLs: []models.URLStatus{
+		{URL: "http://example.com/ok", HTTPStatus: 200, CheckedAt: &checkedAt},
+		{URL: "http://example.com/broken"},
+	}}
+
+	tests := []struct {
+		name             string
+		mockPageInfos    []*models.PageInfo
+		mockEvents       []models.URLStatus
+		mockCheckAllFail bool
+		expectedStatus   int
+		expectedCheckAll bool
+		expectedEvents   []string
+		expectedBody     []string
+	}{
+		{
+			name:           "Already Checked Page",
+			mockPageInfos:  []*models.PageInfo{checkedPage},
+			expectedStatus: http.StatusOK,
+			expectedEvents: []string{"url", "url", "summary"},
+			expectedBody:   []string{`"broken_urls":1`, `"pending_urls":0`},
+		},
+		{
+			name:          "Page Checked In The Background",
+			mockPageInfos: []*models.PageInfo{pendingPage, pendingPage, checkedPage},
+			mockEvents: []models.URLStatus{
+				{URL: "http://example.com/broken", HTTPStatus: 404, LatencyMs: 12},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedCheckAll: true,
+			expectedEvents:   []string{"url", "url", "summary"},
+			expectedBody:     []string{`"latency_ms":12`, `"broken_urls":1`},
+		},
+		{
+			name:          "Status Of Other Resource Type",
+			mockPageInfos: []*models.PageInfo{pendingPage, pendingPage, checkedPage},
+			mockEvents: []models.URLStatus{
+				{URL: "http://example.com/logo.png", HTTPStatus: 200},
+				{URL: "http://example.com/broken", HTTPStatus: 404},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedCheckAll: true,
+			expectedEvents:   []string{"url", "url", "summary"},
+			expectedBody:     []string{`"broken_urls":1`},
+		},
+		{
+			name:             "Check Not Started",
+			mockPageInfos:    []*models.PageInfo{pendingPage, pendingPage, pendingPage},
+			mockEvents:       []models.URLStatus{{URL: "http://example.com/broken"}},
+			mockCheckAllFail: true,
+			expectedStatus:   http.StatusOK,
+			expectedCheckAll: true,
+			expectedEvents:   []string{"url", "summary"},
+			expectedBody:     []string{`"pending_urls":1`},
+		},
+		{
+			name:           "Page Info Not Found",
+			mockPageInfos:  []*models.PageInfo{nil},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   []string{"request ID not found"},
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+
+			calls := 0
+			patchRetrievePageInfo := monkey.Patch(storage.RetrievePageInfo,
+				func(id string) (*models.PageInfo, bool) {
+					pageInfo := test_data.mockPageInfos[min(calls, len(test_data.mockPageInfos)-1)]
+					calls++
+					return pageInfo, pageInfo != nil
+				})
+			defer patchRetrievePageInfo.Unpatch()
+
+			patchSubscribe := monkey.Patch(jobs.Subscribe,
+				func(requestID string, buffer int) (<-chan models.URLStatus, func()) {
+					events := make(chan models.URLStatus, len(test_data.mockEvents))
+					for _, event := range test_data.mockEvents {
+						events <- event
+					}
+					close(events)
+					return events, func() {}
+				})
+			defer patchSubscribe.Unpatch()
+
+			checkAll := false
+			patchCheckAllURLs := monkey.Patch(jobs.CheckAllURLs,
+				func(requestID string, resourceTypes []string) bool {
+					checkAll = true
+					return !test_data.mockCheckAllFail
+				})
+			defer patchCheckAllURLs.Unpatch()
+
+			router := gin.Default()
+			router.GET("/scrape/:id/events", EventsHandler)
+
+			req := httptest.NewRequest(http.MethodGet, "/scrape/mockRequestID/events", nil)
+			resp_recorder := httptest.NewRecorder()
+			router.ServeHTTP(resp_recorder, req)
+
+			assert.Equal(test_type, test_data.expectedStatus, resp_recorder.Code)
+			assert.Equal(test_type, test_data.expectedCheckAll, checkAll)
+
+			var events []string
+			for _, line := range strings.Split(resp_recorder.Body.String(), "\n") {
+				if name, found := strings.CutPrefix(line, "event:"); found {
+					events = append(events, name)
+				}
+			}
+			assert.Equal(test_type, test_data.expectedEvents, events)
+			for _, expected := range test_data.expectedBody {
+				assert.Contains(test_type, resp_recorder.Body.String(), expected)
+			}
+		})
+	}
+}
+
+func TestEventsHandler_RunningCheckOfOtherTypes(test_type *testing.T) {
+	requestID, err := storage.StorePageInfo(&models.PageInfo{URLs: []models.URLStatus{
+		{URL: "http://example.com/page", ResourceType: models.ResourceAnchor},
+		{URL: "http://example.com/a.png", ResourceType: models.ResourceImage},
+	}})
+	assert.NoError(test_type, err)
+
+	checking := make(chan struct{}, 2)
+	release := make(chan struct{})
+	patchCheckURLStatus := monkey.Patch(services.CheckURLStatusWithProgress,
+		func(client *http.Client, urls []models.URLStatus, start, end int,
+			onChecked func(idx int)) int {
+			checking <- struct{}{}
+			<-release
+			now := time.Now()
+			for i := start; i < end; i++ {
+				urls[i].HTTPStatus = http.StatusOK
+				urls[i].CheckedAt = &now
+				onChecked(i)
+			}
+			return 0
+		})
+	defer patchCheckURLStatus.Unpatch()
+
+	// Images are being checked when links of the page are streamed.
+	assert.True(test_type, jobs.CheckAllURLs(requestID, []string{models.ResourceImage}))
+	<-checking
+
+	router := gin.Default()
+	router.GET("/scrape/:id/events", EventsHandler)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Response headers arrive once the stream has joined the running check.
+	resp, err := http.Get(server.URL + "/scrape/" + requestID + "/events")
+	assert.NoError(test_type, err)
+	defer resp.Body.Close()
+	close(release)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(test_type, err)
+	assert.Equal(test_type, 1, strings.Count(string(body), "event:url"))
+	assert.Contains(test_type, string(body), `"url":"http://example.com/page"`)
+	assert.NotContains(test_type, string(body), "a.png")
+	assert.Contains(test_type, string(body), `"pending_urls":0`)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"scraper/jobs"
+	"scraper/logger"
+	"scraper/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Body of the asynchronous scrape job request.
+type jobRequest struct {
+	URL string `json:"url"`
+}
+
+// This handles the request to scrape a URL asynchronously.
+// URL can be given either as the url query parameter or in the JSON request body.
+func CreateJobHandler(context *gin.Context) {
+	rawURL := context.Query("url")
+	if rawURL == "" {
+		var request jobRequest
+		if err := context.ShouldBindJSON(&request); err == nil {
+			rawURL = request.URL
+		}
+	}
+
+	baseURL, err := normalizeScrapeURL(rawURL)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, utils.BuildErrorResponse(err.Error()))
+		return
+	}
+
+	job, err := jobs.Submit(baseURL)
+	if err != nil {
+		logger.Error(err)
+		if errors.Is(err, jobs.ErrQueueFull) {
+			context.JSON(http.StatusServiceUnavailable,
+				utils.BuildErrorResponse("job queue is full, please try again later"))
+			return
+		}
+		context.JSON(http.StatusInternalServerError,
+			utils.BuildErrorResponse("An unexpected error occurred"))
+		return
+	}
+
+	context.Header("Location", fmt.Sprintf("/jobs/%s", job.ID))
+	context.JSON(http.StatusAccepted, job)
+}
+
+// This handles the request to get the state of an asynchronous scrape job.
+func JobStatusHandler(context *gin.Context) {
+	jobID := context.Param("id")
+
+	job, exists := jobs.Get(jobID)
+	if !exists {
+		logger.Debug(fmt.Sprintf("Requested job [%s] not found", jobID))
+		context.JSON(http.StatusNotFound, utils.BuildErrorResponse("job not found"))
+		return
+	}
+
+	context.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"scraper/jobs"
+	"scraper/models"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateJobHandler(test_type *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		mockError      error
+		expectedStatus int
+		expectedURL    string
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "URL In Query",
+			query:          "?url=example.com",
+			expectedStatus: http.StatusAccepted,
+			expectedURL:    "http://example.com",
+		},
+		{
+			name:           "URL In Body",
+			body:           `{"url": "https://example.com"}`,
+			expectedStatus: http.StatusAccepted,
+			expectedURL:    "https://example.com",
+		},
+		{
+			name:           "Missing URL",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"error": "url query parameter is required",
+			},
+		},
+		{
+			name:           "Queue Full",
+			query:          "?url=example.com",
+			mockError:      jobs.ErrQueueFull,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: map[string]interface{}{
+				"error": "job queue is full, please try again later",
+			},
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+
+			submittedURL := ""
+			patchSubmit := monkey.Patch(jobs.Submit, func(url string) (models.Job, error) {
+				submittedURL = url
+				if test_data.mockError != nil {
+					return models.Job{}, test_data.mockError
+				}
+				return models.Job{ID: "mockJobID", URL: url, State: models.JobQueued}, nil
+			})
+			defer patchSubmit.Unpatch()
+
+			router := gin.Default()
+			router.POST("/jobs", CreateJobHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/jobs"+test_data.query,
+				strings.NewReader(test_data.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp_recorder := httptest.NewRecorder()
+			router.ServeHTTP(resp_recorder, req)
+
+			assert.Equal(test_type, test_data.expectedStatus, resp_recorder.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(resp_recorder.Body.Bytes(), &response)
+			assert.NoError(test_type, err)
+
+			if test_data.expectedStatus == http.StatusAccepted {
+				assert.Equal(test_type, test_data.expectedURL, submittedURL)
+				assert.Equal(test_type, "/jobs/mockJobID", resp_recorder.Header().Get("Location"))
+				assert.Equal(test_type, "queued", response["state"])
+			}
+			for k, v := range test_data.expectedBody {
+				assert.Equal(test_type, v, response[k])
+			}
+		})
+	}
+}
+
+func TestJobStatusHandler(test_type *testing.T) {
+	tests := []struct {
+		name           string
+		jobID          string
+		mockJob        models.Job
+		mockExists     bool
+		expectedStatus int
+		expectedState  interface{}
+	}{
+		{
+			name:           "Job Found",
+			jobID:          "mockJobID",
+			mockJob:        models.Job{ID: "mockJobID", State: models.JobChecking},
+			mockExists:     true,
+			expectedStatus: http.StatusOK,
+			expectedState:  "checking",
+		},
+		{
+			name:           "Job Not Found",
+			jobID:          "nonExistentID",
+			mockExists:     false,
+			expectedStatus: http.StatusNotFound,
+			expectedState:  nil,
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+
+			patchGet := monkey.Patch(jobs.Get, func(id string) (models.Job, bool) {
+				return test_data.mockJob, test_data.mockExists
+			})
+			defer patchGet.Unpatch()
+
+			router := gin.Default()
+			router.GET("/jobs/:id", JobStatusHandler)
+
+			req := httptest.NewRequest(http.MethodGet, "/jobs/"+test_data.jobID, nil)
+			resp_recorder := httptest.NewRecorder()
+			router.ServeHTTP(resp_recorder, req)
+
+			assert.Equal(test_type, test_data.expectedStatus, resp_recorder.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(resp_recorder.Body.Bytes(), &response)
+			assert.NoError(test_type, err)
+			assert.Equal(test_type, test_data.expectedState, response["state"])
+		})
+	}
+}
@@ -1,18 +1,19 @@
 package handlers
 
 import (
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
-	"time"
 
 	"scraper/config"
+	"scraper/jobs"
 	"scraper/logger"
+	"scraper/models"
 	"scraper/services"
 	"scraper/storage"
 	"scraper/utils"
@@ -23,35 +24,22 @@ import (
 
 // This handles the initial scraping request received from the client.
 func ScrapeHandler(context *gin.Context) {
-	baseURL := context.Query("url")
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // Disable TLS verification
-		},
-		Timeout: time.Duration(config.GetOutgoingScrapeRequestTimeout()) * time.Second,
-	}
+	client := services.NewScrapeClient()
 
-	if baseURL == "" {
-		logger.Debug("URL query parameter is required")
-		context.JSON(http.StatusBadRequest,
-			utils.BuildErrorResponse("url query parameter is required"))
+	baseURL, err := normalizeScrapeURL(context.Query("url"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, utils.BuildErrorResponse(err.Error()))
+		return
+	}
+	resourceTypes, err := parseResourceTypes(context.Query("types"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, utils.BuildErrorResponse(err.Error()))
 		return
-	} else {
-		if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
-			baseURL = "http://" + baseURL
-		}
-
-		baseUrlParsed, _ := url.Parse(baseURL)
-		_, err := publicsuffix.EffectiveTLDPlusOne(baseUrlParsed.Host)
-		if err != nil {
-			logger.Error(err)
-			context.JSON(http.StatusBadRequest,
-				utils.BuildErrorResponse("Invalid URL format, please provide a valid URL."))
-			return
-		}
 	}
 
-	pageInfo, err := services.FetchPageInfo(client, baseURL)
+	// Content extraction is costlier, so it is done only if it is requested.
+	options := services.ParseOptions{ExtractContent: context.Query("content") == "true"}
+	pageInfo, err := services.FetchPageInfo(client, baseURL, options)
 	if err != nil {
 		logger.Error(err)
 		var netErr net.Error
@@ -75,33 +63,39 @@ func ScrapeHandler(context *gin.Context) {
 
 	// We store scraped page info in-memory to use with pagination later.
 	// Stored page infomation mapped to the returned request ID.
-	requestID := storage.StorePageInfo(pageInfo)
+	requestID, err := storage.StorePageInfo(pageInfo)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError,
+			utils.BuildErrorResponse("An unexpected error occurred"))
+		return
+	}
+	// Only URLs of the requested resource types are listed and checked.
+	pageInfo.URLs = services.FilterURLsByType(pageInfo.URLs, resourceTypes)
 	// Here we check the status of 10 (config.PageSize) scraped URLs.
-	inaccessibleCount := services.CheckURLStatus(client, pageInfo.URLs, 0,
-		min(config.GetURLCheckPageSize(), len(pageInfo.URLs)))
+	end := min(config.GetURLCheckPageSize(), len(pageInfo.URLs))
+	inaccessibleCount := services.CheckURLStatus(client, pageInfo.URLs, 0, end)
+	// Checked statuses are recorded so that the first page is not checked again.
+	storage.UpdateURLStatuses(requestID, pageInfo.URLs[:end])
+	// Rest of the URLs are checked in the background only if it is requested.
+	if context.Query("check_all") == "true" {
+		jobs.CheckAllURLs(requestID, resourceTypes)
+	}
 	totalPages := utils.CalculateTotalPages(len(pageInfo.URLs), config.GetURLCheckPageSize())
 
 	context.JSON(http.StatusOK, utils.BuildPageResponse(requestID, 1, totalPages, pageInfo,
-		inaccessibleCount, 0, min(config.GetURLCheckPageSize(), len(pageInfo.URLs))))
+		inaccessibleCount, 0, end, typesQuery(context)))
 }
 
 // This handles subsequent pagination requests to check status of URLs.
 func PageHandler(context *gin.Context) {
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // Disable TLS verification
-		},
-		Timeout: time.Duration(config.GetOutgoingAccessibilityCheckTimeout()) * time.Second,
-	}
+	client := services.NewURLCheckClient()
 	// Request ID is required to fetch infromation from the in-memory storage.
 	requestID := context.Param("id")
 	pageNumStr := context.Param("page")
 
-	// Retrieve page information from in-memory storage using the request ID.
-	pageInfo, exists := storage.RetrievePageInfo(requestID)
+	// Retrieve page information from the storage using the request ID.
+	pageInfo, exists := retrievePageInfo(context, requestID)
 	if !exists {
-		logger.Debug(fmt.Sprintf("Requested ID [%s] not found in the local storage", requestID))
-		context.JSON(http.StatusNotFound, utils.BuildErrorResponse("request ID not found"))
 		return
 	}
 
@@ -112,6 +106,13 @@ func PageHandler(context *gin.Context) {
 		return
 	}
 
+	resourceTypes, err := parseResourceTypes(context.Query("types"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, utils.BuildErrorResponse(err.Error()))
+		return
+	}
+	pageInfo.URLs = services.FilterURLsByType(pageInfo.URLs, resourceTypes)
+
 	start, end := utils.CalculatePageBounds(pageNum, len(pageInfo.URLs), config.GetURLCheckPageSize())
 	if start >= len(pageInfo.URLs) {
 		logger.Debug(fmt.Sprintf("Requested page [%d] not found", pageNum))
@@ -119,10 +120,116 @@ func PageHandler(context *gin.Context) {
 		return
 	}
 
-	// Check the URL status for URLs on the given pagination page.
-	inaccessibleCount := services.CheckURLStatus(client, pageInfo.URLs, start, end)
+	// Already checked pages are served from the storage unless a refresh is requested.
+	var inaccessibleCount int
+	if context.Query("refresh") != "true" && services.IsURLStatusChecked(pageInfo.URLs[start:end]) {
+		inaccessibleCount = services.CountInaccessibleURLs(pageInfo.URLs[start:end])
+	} else {
+		// Check the URL status for URLs on the given pagination page.
+		inaccessibleCount = services.CheckURLStatus(client, pageInfo.URLs, start, end)
+		storage.UpdateURLStatuses(requestID, pageInfo.URLs[start:end])
+	}
 	totalPages := utils.CalculateTotalPages(len(pageInfo.URLs), config.GetURLCheckPageSize())
 
 	context.JSON(http.StatusOK, utils.BuildPageResponse(requestID, pageNum, totalPages, pageInfo,
-		inaccessibleCount, start, end))
+		inaccessibleCount, start, end, typesQuery(context)))
+}
+
+// This handles the request to summarize URL statuses of a scraped page.
+func SummaryHandler(context *gin.Context) {
+	requestID := context.Param("id")
+
+	resourceTypes, err := parseResourceTypes(context.Query("types"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, utils.BuildErrorResponse(err.Error()))
+		return
+	}
+	pageInfo, exists := retrievePageInfo(context, requestID)
+	if !exists {
+		return
+	}
+
+	context.JSON(http.StatusOK, buildCheckSummary(requestID,
+		services.FilterURLsByType(pageInfo.URLs, resourceTypes)))
+}
+
+// This is to summarize statuses of the given URLs of a page.
+func buildCheckSummary(requestID string, urls []models.URLStatus) models.CheckSummary {
+	accessible, inaccessible, pending := services.SummarizeURLStatus(urls)
+	return models.CheckSummary{
+		RequestID:   requestID,
+		TotalURLs:   len(urls),
+		OKURLs:      accessible,
+		BrokenURLs:  inaccessible,
+		PendingURLs: pending,
+		Checking:    jobs.IsCheckingURLs(requestID),
+	}
+}
+
+// This is to retrieve page info stored under the request ID.
+// Responds with an error and returns false if the page info is not available.
+func retrievePageInfo(context *gin.Context, requestID string) (*models.PageInfo, bool) {
+	pageInfo, exists := storage.RetrievePageInfo(requestID)
+	if !exists && storage.IsExpired(requestID) {
+		logger.Debug(fmt.Sprintf("Requested ID [%s] has expired in the local storage", requestID))
+		context.JSON(http.StatusGone, utils.BuildErrorResponse("request ID has expired"))
+		return nil, false
+	}
+	if !exists {
+		logger.Debug(fmt.Sprintf("Requested ID [%s] not found in the local storage", requestID))
+		context.JSON(http.StatusNotFound, utils.BuildErrorResponse("request ID not found"))
+		return nil, false
+	}
+	return pageInfo, true
+}
+
+// This is to read the resource types whose URLs are requested, given as a comma separated list.
+// Only links are listed by default, and "all" selects every type.
+func parseResourceTypes(rawTypes string) ([]string, error) {
+	switch rawTypes {
+	case "":
+		return services.DefaultResourceTypes, nil
+	case "all":
+		return nil, nil
+	}
+
+	var resourceTypes []string
+	for _, resourceType := range strings.Split(rawTypes, ",") {
+		resourceType = strings.TrimSpace(resourceType)
+		if !slices.Contains(models.ResourceTypes, resourceType) {
+			return nil, fmt.Errorf("unknown resource type: %s", resourceType)
+		}
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	return resourceTypes, nil
+}
+
+// This is to build the query selecting the same resource types on other pages.
+func typesQuery(context *gin.Context) string {
+	if rawTypes := context.Query("types"); rawTypes != "" {
+		return url.Values{"types": {rawTypes}}.Encode()
+	}
+	return ""
+}
+
+// This is to validate the URL requested to scrape and default its scheme to HTTP.
+func normalizeScrapeURL(baseURL string) (string, error) {
+	if baseURL == "" {
+		logger.Debug("URL query parameter is required")
+		return "", errors.New("url query parameter is required")
+	}
+
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		baseURL = "http://" + baseURL
+	}
+
+	baseUrlParsed, err := url.Parse(baseURL)
+	if err == nil {
+		_, err = publicsuffix.EffectiveTLDPlusOne(baseUrlParsed.Host)
+	}
+	if err != nil {
+		logger.Error(err)
+		return "", errors.New("Invalid URL format, please provide a valid URL.")
+	}
+	return baseURL, nil
 }
@@ -2,14 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"scraper/config"
+	"scraper/jobs"
 	"scraper/models"
 	"scraper/services"
 	"scraper/storage"
 	"scraper/utils"
 	"testing"
+	"time"
 
 	"bou.ke/monkey"
 	"github.com/gin-gonic/gin"
@@ -23,8 +27,11 @@ func TestScrapeHandler(test_type *testing.T) {
 		mockPageInfo   *models.PageInfo
 		mockError      error
 		mockRequestID  string
+		mockStoreError error
 		expectedStatus int
 		expectedBody   map[string]interface{}
+		expectCheckAll bool
+		expectedTypes  []string
 	}{
 		{
 			name: "Valid URL",
@@ -44,6 +51,54 @@ func TestScrapeHandler(test_type *testing.T) {
 			mockRequestID:  "mockRequestID",
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "Valid URL With Background Check",
+			queryParams: map[string]string{
+				"url":       "http://example.com",
+				"check_all": "true",
+			},
+			mockPageInfo: &models.PageInfo{
+				HeadingCounts: map[string]int{},
+				URLs:          []models.URLStatus{},
+			},
+			mockError:      nil,
+			mockRequestID:  "mockRequestID",
+			expectedStatus: http.StatusOK,
+			expectCheckAll: true,
+			expectedTypes:  services.DefaultResourceTypes,
+		},
+		{
+			name: "Background Check Of Requested Types",
+			queryParams: map[string]string{
+				"url":       "http://example.com",
+				"check_all": "true",
+				"types":     "image,script",
+			},
+			mockPageInfo: &models.PageInfo{
+				HeadingCounts: map[string]int{},
+				URLs:          []models.URLStatus{},
+			},
+			mockError:      nil,
+			mockRequestID:  "mockRequestID",
+			expectedStatus: http.StatusOK,
+			expectCheckAll: true,
+			expectedTypes:  []string{models.ResourceImage, models.ResourceScript},
+		},
+		{
+			name: "Storage Error",
+			queryParams: map[string]string{
+				"url": "http://example.com",
+			},
+			mockPageInfo: &models.PageInfo{
+				HeadingCounts: map[string]int{},
+				URLs:          []models.URLStatus{},
+			},
+			mockStoreError: assert.AnError,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: map[string]interface{}{
+				"error": "An unexpected error occurred",
+			},
+		},
 		{
 			name:           "Missing URL",
 			queryParams:    map[string]string{"url": ""},
@@ -116,17 +171,28 @@ func TestScrapeHandler(test_type *testing.T) {
 		test_type.Run(test_data.name, func(test_type *testing.T) {
 
 			patchFetchPageInfo := monkey.Patch(services.FetchPageInfo,
-				func(client *http.Client, url string) (*models.PageInfo, error) {
+				func(client *http.Client, url string,
+					options services.ParseOptions) (*models.PageInfo, error) {
 					return test_data.mockPageInfo, test_data.mockError
 				})
 			defer patchFetchPageInfo.Unpatch()
 
 			patchStorePageInfo := monkey.Patch(storage.StorePageInfo,
-				func(info *models.PageInfo) string {
-					return test_data.mockRequestID
+				func(info *models.PageInfo) (string, error) {
+					return test_data.mockRequestID, test_data.mockStoreError
 				})
 			defer patchStorePageInfo.Unpatch()
 
+			checkAll := false
+			var checkAllTypes []string
+			patchCheckAllURLs := monkey.Patch(jobs.CheckAllURLs,
+				func(requestID string, resourceTypes []string) bool {
+					checkAll = true
+					checkAllTypes = resourceTypes
+					return true
+				})
+			defer patchCheckAllURLs.Unpatch()
+
 			router := gin.Default()
 			router.GET("/scrape", ScrapeHandler)
 
@@ -142,6 +208,8 @@ func TestScrapeHandler(test_type *testing.T) {
 			router.ServeHTTP(resp_recorder, req)
 
 			assert.Equal(test_type, test_data.expectedStatus, resp_recorder.Code)
+			assert.Equal(test_type, test_data.expectCheckAll, checkAll)
+			assert.Equal(test_type, test_data.expectedTypes, checkAllTypes)
 
 			if test_data.expectedBody != nil {
 				var response map[string]interface{}
@@ -157,13 +225,17 @@ func TestScrapeHandler(test_type *testing.T) {
 }
 
 func TestPageHandler(test_type *testing.T) {
+	checkedAt := time.Now()
 	tests := []struct {
 		name           string
 		requestID      string
 		pageNum        string
+		query          string
 		mockPageInfo   *models.PageInfo
 		mockExists     bool
+		mockExpired    bool
 		expectedStatus int
+		expectedCheck  bool
 		expectedBody   map[string]interface{}
 	}{
 		{
@@ -177,6 +249,34 @@ func TestPageHandler(test_type *testing.T) {
 			},
 			mockExists:     true,
 			expectedStatus: http.StatusOK,
+			expectedCheck:  true,
+		},
+		{
+			name:      "Cached Page Request",
+			requestID: "mockRequestID",
+			pageNum:   "1",
+			mockPageInfo: &models.PageInfo{
+				URLs: []models.URLStatus{
+					{URL: "http://example.com", HTTPStatus: 404, CheckedAt: &checkedAt},
+				},
+			},
+			mockExists:     true,
+			expectedStatus: http.StatusOK,
+			expectedCheck:  false,
+		},
+		{
+			name:      "Refreshed Page Request",
+			requestID: "mockRequestID",
+			pageNum:   "1",
+			query:     "?refresh=true",
+			mockPageInfo: &models.PageInfo{
+				URLs: []models.URLStatus{
+					{URL: "http://example.com", HTTPStatus: 404, CheckedAt: &checkedAt},
+				},
+			},
+			mockExists:     true,
+			expectedStatus: http.StatusOK,
+			expectedCheck:  true,
 		},
 		{
 			name:           "Page Info Not Found",
@@ -189,6 +289,30 @@ func TestPageHandler(test_type *testing.T) {
 				"error": "request ID not found",
 			},
 		},
+		{
+			name:           "Page Info Expired",
+			requestID:      "expiredID",
+			pageNum:        "1",
+			mockPageInfo:   nil,
+			mockExists:     false,
+			mockExpired:    true,
+			expectedStatus: http.StatusGone,
+			expectedBody: map[string]interface{}{
+				"error": "request ID has expired",
+			},
+		},
+		{
+			name:           "Unknown Resource Type",
+			requestID:      "mockRequestID",
+			pageNum:        "1",
+			query:          "?types=image,font",
+			mockPageInfo:   &models.PageInfo{},
+			mockExists:     true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"error": "unknown resource type: font",
+			},
+		},
 		{
 			name:           "Invalid Page Number",
 			requestID:      "mockRequestID",
@@ -211,16 +335,31 @@ func TestPageHandler(test_type *testing.T) {
 				})
 			defer patchRetrievePageInfo.Unpatch()
 
+			patchIsExpired := monkey.Patch(storage.IsExpired, func(id string) bool {
+				return test_data.mockExpired
+			})
+			defer patchIsExpired.Unpatch()
+
 			patchCalculatePageBounds := monkey.Patch(utils.CalculatePageBounds,
 				func(pageNum, totalItems, pageSize int) (int, int) {
 					return 0, 1
 				})
 			defer patchCalculatePageBounds.Unpatch()
 
+			// CheckURLStatus delegates to CheckURLStatusWithProgress.
+			checked := false
+			patchCheckURLStatus := monkey.Patch(services.CheckURLStatusWithProgress,
+				func(client *http.Client, urls []models.URLStatus, start, end int,
+					onChecked func(idx int)) int {
+					checked = true
+					return 0
+				})
+			defer patchCheckURLStatus.Unpatch()
+
 			router := gin.Default()
 			router.GET("/page/:id/:page", PageHandler)
 
-			url := "/page/" + test_data.requestID + "/" + test_data.pageNum
+			url := "/page/" + test_data.requestID + "/" + test_data.pageNum + test_data.query
 			req := httptest.NewRequest(http.MethodGet, url, nil)
 
 			// Perform the request
@@ -228,6 +367,7 @@ func TestPageHandler(test_type *testing.T) {
 			router.ServeHTTP(resp_recorder, req)
 
 			assert.Equal(test_type, test_data.expectedStatus, resp_recorder.Code)
+			assert.Equal(test_type, test_data.expectedCheck, checked)
 
 			if test_data.expectedBody != nil {
 				var response map[string]interface{}
@@ -241,3 +381,204 @@ func TestPageHandler(test_type *testing.T) {
 		})
 	}
 }
+
+func TestSummaryHandler(test_type *testing.T) {
+	checkedAt := time.Now()
+	tests := []struct {
+		name           string
+		requestID      string
+		query          string
+		mockPageInfo   *models.PageInfo
+		mockExists     bool
+		mockChecking   bool
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:      "Summary Of Partially Checked Page",
+			requestID: "mockRequestID",
+			mockPageInfo: &models.PageInfo{
+				URLs: []models.URLStatus{
+					{URL: "http://example.com/ok", HTTPStatus: 200, CheckedAt: &checkedAt},
+					{URL: "http://example.com/broken", HTTPStatus: 404, CheckedAt: &checkedAt},
+					{URL: "http://example.com/error", Error: "error", CheckedAt: &checkedAt},
+					{URL: "http://example.com/pending"},
+				},
+			},
+			mockExists:     true,
+			mockChecking:   true,
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"total_urls":   float64(4),
+				"ok_urls":      float64(1),
+				"broken_urls":  float64(2),
+				"pending_urls": float64(1),
+				"checking":     true,
+			},
+		},
+		{
+			name:      "Summary Of Requested Types",
+			requestID: "mockRequestID",
+			query:     "?types=image",
+			mockPageInfo: &models.PageInfo{
+				URLs: []models.URLStatus{
+					{URL: "http://example.com/ok", HTTPStatus: 200, CheckedAt: &checkedAt},
+					{URL: "http://example.com/a.png", ResourceType: models.ResourceImage,
+						HTTPStatus: 404, CheckedAt: &checkedAt},
+					{URL: "http://example.com/b.png", ResourceType: models.ResourceImage},
+					{URL: "http://example.com/app.js", ResourceType: models.ResourceScript},
+				},
+			},
+			mockExists:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"total_urls":   float64(2),
+				"ok_urls":      float64(0),
+				"broken_urls":  float64(1),
+				"pending_urls": float64(1),
+				"checking":     false,
+			},
+		},
+		{
+			name:           "Unknown Resource Type",
+			requestID:      "mockRequestID",
+			query:          "?types=font",
+			mockPageInfo:   &models.PageInfo{},
+			mockExists:     true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"error": "unknown resource type: font",
+			},
+		},
+		{
+			name:           "Page Info Not Found",
+			requestID:      "nonExistentID",
+			mockExists:     false,
+			expectedStatus: http.StatusNotFound,
+			expectedBody: map[string]interface{}{
+				"error": "request ID not found",
+			},
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+
+			patchRetrievePageInfo := monkey.Patch(storage.RetrievePageInfo,
+				func(id string) (*models.PageInfo, bool) {
+					return test_data.mockPageInfo, test_data.mockExists
+				})
+			defer patchRetrievePageInfo.Unpatch()
+
+			patchIsCheckingURLs := monkey.Patch(jobs.IsCheckingURLs, func(id string) bool {
+				return test_data.mockChecking
+			})
+			defer patchIsCheckingURLs.Unpatch()
+
+			router := gin.Default()
+			router.GET("/scrape/:id/summary", SummaryHandler)
+
+			req := httptest.NewRequest(http.MethodGet,
+				"/scrape/"+test_data.requestID+"/summary"+test_data.query, nil)
+			resp_recorder := httptest.NewRecorder()
+			router.ServeHTTP(resp_recorder, req)
+
+			assert.Equal(test_type, test_data.expectedStatus, resp_recorder.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(resp_recorder.Body.Bytes(), &response)
+			assert.NoError(test_type, err)
+
+			for k, v := range test_data.expectedBody {
+				assert.Equal(test_type, v, response[k])
+			}
+		})
+	}
+}
+
+func TestPageHandler_ResourceTypes(test_type *testing.T) {
+	// One link, a page and a half of images and a script.
+	pageSize := config.GetURLCheckPageSize()
+	pageInfo := &models.PageInfo{
+		URLs: []models.URLStatus{{URL: "http://example.com/", ResourceType: models.ResourceAnchor}},
+	}
+	for i := 0; i < pageSize+pageSize/2; i++ {
+		pageInfo.URLs = append(pageInfo.URLs, models.URLStatus{
+			URL:          fmt.Sprintf("http://example.com/%d.png", i),
+			ResourceType: models.ResourceImage,
+		})
+	}
+	pageInfo.URLs = append(pageInfo.URLs,
+		models.URLStatus{URL: "http://example.com/app.js", ResourceType: models.ResourceScript})
+
+	tests := []struct {
+		name             string
+		query            string
+		expectedFirstURL string
+		expectedChecked  int
+		expectedNextPage interface{}
+	}{
+		{
+			name:             "Links By Default",
+			expectedFirstURL: "http://example.com/",
+			expectedChecked:  1,
+		},
+		{
+			name:             "Selected Types",
+			query:            "?types=image,script",
+			expectedFirstURL: "http://example.com/0.png",
+			expectedChecked:  pageSize,
+			expectedNextPage: "/scrape/mockRequestID/2?types=image%2Cscript",
+		},
+		{
+			name:             "All Types",
+			query:            "?types=all",
+			expectedFirstURL: "http://example.com/",
+			expectedChecked:  pageSize,
+			expectedNextPage: "/scrape/mockRequestID/2?types=all",
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			patchRetrievePageInfo := monkey.Patch(storage.RetrievePageInfo,
+				func(id string) (*models.PageInfo, bool) {
+					info := *pageInfo
+					return &info, true
+				})
+			defer patchRetrievePageInfo.Unpatch()
+
+			var checked []string
+			patchCheckURLStatus := monkey.Patch(services.CheckURLStatusWithProgress,
+				func(client *http.Client, urls []models.URLStatus, start, end int,
+					onChecked func(idx int)) int {
+					for _, urlStatus := range urls[start:end] {
+						checked = append(checked, urlStatus.URL)
+					}
+					return 0
+				})
+			defer patchCheckURLStatus.Unpatch()
+
+			patchUpdateURLStatuses := monkey.Patch(storage.UpdateURLStatuses,
+				func(id string, statuses []models.URLStatus) bool { return true })
+			defer patchUpdateURLStatuses.Unpatch()
+
+			router := gin.Default()
+			router.GET("/scrape/:id/:page", PageHandler)
+
+			req := httptest.NewRequest(http.MethodGet, "/scrape/mockRequestID/1"+test_data.query, nil)
+			resp_recorder := httptest.NewRecorder()
+			router.ServeHTTP(resp_recorder, req)
+
+			assert.Equal(test_type, http.StatusOK, resp_recorder.Code)
+			assert.Len(test_type, checked, test_data.expectedChecked)
+			assert.Equal(test_type, test_data.expectedFirstURL, checked[0])
+
+			var response map[string]interface{}
+			err := json.Unmarshal(resp_recorder.Body.Bytes(), &response)
+			assert.NoError(test_type, err)
+			pagination := response["pagination"].(map[string]interface{})
+			assert.Equal(test_type, test_data.expectedNextPage, pagination["next_page"])
+		})
+	}
+}
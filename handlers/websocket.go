@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"scraper/config"
+	"scraper/jobs"
+	"scraper/logger"
+	"scraper/models"
+	"scraper/services"
+	"scraper/storage"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// Error message sent for commands on requests which were not scraped in the session.
+const notSessionRequestMessage = "request ID was not scraped in this session"
+
+// Interactive scrape session over a single WebSocket connection.
+type session struct {
+	conn *websocket.Conn
+	// Serializes writes to the connection.
+	writeMu sync.Mutex
+	// Cancels subscriptions to background checks started by the session.
+	subscriptionsMu sync.Mutex
+	unsubscribes    []func()
+	closed          bool
+	// IDs of requests scraped in the session, the only ones its commands can act on.
+	requestIDsMu sync.Mutex
+	requestIDs   map[string]bool
+}
+
+// This handles interactive scrape sessions over WebSocket.
+// Client sends scrape, cancel and prioritise commands, and receives parse results and
+// URL statuses as they are checked in the background.
+func WebSocketHandler(context *gin.Context) {
+	server := websocket.Server{
+		Handshake: func(wsConfig *websocket.Config, request *http.Request) error {
+			origin, err := websocket.Origin(wsConfig, request)
+			if err != nil {
+				return err
+			}
+			wsConfig.Origin = origin
+			return checkOrigin(origin, request.Host, config.GetWebSocketAllowedOrigins())
+		},
+		Handler: func(conn *websocket.Conn) {
+			(&session{conn: conn, requestIDs: make(map[string]bool)}).serve()
+		},
+	}
+	server.ServeHTTP(context.Writer, context.Request)
+}
+
+// This is to check if the origin is allowed to open a session. Pages of the server itself are
+// always allowed, other origins only if they are in the comma separated allowed origins.
+// Clients which are not browsers usually send no origin and are allowed too, while browsers
+// always send one.
+func checkOrigin(origin *url.URL, host, allowedOrigins string) error {
+	if origin == nil {
+		return nil
+	}
+	if strings.EqualFold(origin.Host, host) {
+		return nil
+	}
+	for _, allowed := range strings.Split(allowedOrigins, ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"),
+			origin.Scheme+"://"+origin.Host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %s is not allowed", origin)
+}
+
+// This is to receive and execute commands until the client closes the connection.
+func (session *session) serve() {
+	defer session.close()
+
+	for {
+		var command models.SessionCommand
+		if err := websocket.JSON.Receive(session.conn, &command); err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Error(err)
+			}
+			return
+		}
+
+		switch command.Action {
+		case models.SessionScrape:
+			// Scraping runs aside, so commands keep being received in the meantime.
+			go session.scrape(command.URL)
+		case models.SessionCancel:
+			if !session.owns(command.RequestID) {
+				session.sendError(command.RequestID, notSessionRequestMessage)
+				continue
+			}
+			if !jobs.CancelCheck(command.RequestID) {
+				session.sendError(command.RequestID, "no URL check in progress for the request ID")
+				continue
+			}
+			session.send(models.SessionMessage{Type: models.SessionCancelled,
+				RequestID: command.RequestID})
+		case models.SessionPrioritise:
+			if !session.owns(command.RequestID) {
+				session.sendError(command.RequestID, notSessionRequestMessage)
+				continue
+			}
+			moved := jobs.PrioritiseURLs(command.RequestID, command.URLs)
+			session.send(models.SessionMessage{Type: models.SessionPrioritised,
+				RequestID: command.RequestID, Data: gin.H{"prioritised_urls": moved}})
+		default:
+			session.sendError("", fmt.Sprintf("unknown action: %s", command.Action))
+		}
+	}
+}
+
+// This is to scrape the given URL and check all found URLs in the background.
+func (session *session) scrape(rawURL string) {
+	baseURL, err := normalizeScrapeURL(rawURL)
+	if err != nil {
+		session.sendError("", err.Error())
+		return
+	}
+
+	pageInfo, err := services.FetchPageInfo(services.NewScrapeClient(), baseURL,
+		services.ParseOptions{})
+	if err != nil {
+		logger.Error(err)
+		session.sendError("", "Failed to reach the requested URL")
+		return
+	}
+
+	requestID, err := storage.StorePageInfo(pageInfo)
+	if err != nil {
+		session.sendError("", "An unexpected error occurred")
+		return
+	}
+	session.requestIDsMu.Lock()
+	session.requestIDs[requestID] = true
+	session.requestIDsMu.Unlock()
+	// Links are checked, the same as a scrape request without types does, and all of them
+	// are sent so the client knows all URLs it can prioritise.
+	pageInfo.URLs = services.FilterURLsByType(pageInfo.URLs, services.DefaultResourceTypes)
+	session.send(models.SessionMessage{Type: models.SessionScraped, RequestID: requestID,
+		Data: pageInfo})
+
+	// Subscribe before starting the check, so no status is missed.
+	events, unsubscribe := jobs.Subscribe(requestID, len(pageInfo.URLs)+1)
+	session.subscriptionsMu.Lock()
+	if session.closed {
+		session.subscriptionsMu.Unlock()
+		unsubscribe()
+		return
+	}
+	session.unsubscribes = append(session.unsubscribes, unsubscribe)
+	session.subscriptionsMu.Unlock()
+
+	if !jobs.CheckAllURLs(requestID, services.DefaultResourceTypes) {
+		// Page has expired or its check has been cancelled, so no status is going to be sent.
+		unsubscribe()
+	}
+	session.forward(requestID, indexURLs(pageInfo.URLs), events)
+}
+
+// This is to forward checked statuses of the given URLs to the client and summarize at the end.
+// Statuses of other URLs checked along, for a request of other resource types, are skipped.
+func (session *session) forward(requestID string, urls map[string]models.URLStatus,
+	events <-chan models.URLStatus) {
+	for checked := range events {
+		if urlStatus, found := urls[checked.URL]; found {
+			urlStatus.SetCheckResult(checked)
+			session.send(models.SessionMessage{Type: models.SessionURLStatus,
+				RequestID: requestID, Data: urlStatus})
+		}
+	}
+
+	if session.isClosed() {
+		return
+	}
+	if pageInfo, exists := storage.RetrievePageInfo(requestID); exists {
+		session.send(models.SessionMessage{Type: models.SessionSummary,
+			RequestID: requestID, Data: buildCheckSummary(requestID,
+				services.FilterURLsByType(pageInfo.URLs, services.DefaultResourceTypes))})
+	}
+}
+
+// This is to check if the request was scraped in the session.
+func (session *session) owns(requestID string) bool {
+	session.requestIDsMu.Lock()
+	defer session.requestIDsMu.Unlock()
+
+	return session.requestIDs[requestID]
+}
+
+// This is to send the message to the client.
+func (session *session) send(message models.SessionMessage) {
+	session.writeMu.Lock()
+	defer session.writeMu.Unlock()
+
+	if err := websocket.JSON.Send(session.conn, message); err != nil {
+		logger.Error(err)
+	}
+}
+
+// This is to send an error message to the client.
+func (session *session) sendError(requestID, message string) {
+	session.send(models.SessionMessage{Type: models.SessionError, RequestID: requestID,
+		Error: message})
+}
+
+// This is to stop forwarding URL statuses once the client has gone away.
+// Background checks started by the session keep running and record their results.
+func (session *session) close() {
+	session.subscriptionsMu.Lock()
+	defer session.subscriptionsMu.Unlock()
+
+	session.closed = true
+	for _, unsubscribe := range session.unsubscribes {
+		unsubscribe()
+	}
+	session.unsubscribes = nil
+}
+
+// This is to check if the client has gone away.
+func (session *session) isClosed() bool {
+	session.subscriptionsMu.Lock()
+	defer session.subscriptionsMu.Unlock()
+
+	return session.closed
+}
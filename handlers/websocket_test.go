@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"scraper/models"
+	"scraper/services"
+	"strings"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+// This is to receive the next message of the session or fail after a timeout.
+func receiveMessage(test_type *testing.T, conn *websocket.Conn) map[string]interface{} {
+	assert.NoError(test_type, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+
+	var message map[string]interface{}
+	if err := websocket.JSON.Receive(conn, &message); err != nil {
+		test_type.Fatalf("Failed to receive a session message: %v", err)
+	}
+	return message
+}
+
+func TestWebSocketHandler(test_type *testing.T) {
+	patchFetchPageInfo := monkey.Patch(services.FetchPageInfo,
+		func(client *http.Client, url string,
+			options services.ParseOptions) (*models.PageInfo, error) {
+			return &models.PageInfo{Title: "Example Title", URLs: []models.URLStatus{
+				{URL: "http://example.com/ok"},
+				{URL: "http://example.com/broken"},
+			}}, nil
+		})
+	defer patchFetchPageInfo.Unpatch()
+
+	patchCheckURLStatus := monkey.Patch(services.CheckURLStatusWithProgress,
+		func(client *http.Client, urls []models.URLStatus, start, end int,
+			onChecked func(idx int)) int {
+			now := time.Now()
+			for i := start; i < end; i++ {
+				urls[i].HTTPStatus = http.StatusOK
+				if strings.HasSuffix(urls[i].URL, "broken") {
+					urls[i].HTTPStatus = http.StatusNotFound
+				}
+				urls[i].CheckedAt = &now
+				onChecked(i)
+			}
+			return 1
+		})
+	defer patchCheckURLStatus.Unpatch()
+
+	router := gin.Default()
+	router.GET("/ws", WebSocketHandler)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", "",
+		server.URL)
+	assert.NoError(test_type, err)
+	defer conn.Close()
+
+	// Scrape command responds with the parse result, URL statuses and a summary.
+	assert.NoError(test_type, websocket.JSON.Send(conn,
+		models.SessionCommand{Action: models.SessionScrape, URL: "example.com"}))
+
+	scraped := receiveMessage(test_type, conn)
+	assert.Equal(test_type, models.SessionScraped, scraped["type"])
+	assert.Equal(test_type, "Example Title", scraped["data"].(map[string]interface{})["title"])
+	requestID, _ := scraped["request_id"].(string)
+
+	var statuses []interface{}
+	for i := 0; i < 2; i++ {
+		message := receiveMessage(test_type, conn)
+		assert.Equal(test_type, models.SessionURLStatus, message["type"])
+		assert.Equal(test_type, requestID, message["request_id"])
+		statuses = append(statuses, message["data"].(map[string]interface{})["http_status"])
+	}
+	assert.ElementsMatch(test_type, []interface{}{float64(200), float64(404)}, statuses)
+
+	summary := receiveMessage(test_type, conn)
+	assert.Equal(test_type, models.SessionSummary, summary["type"])
+	assert.Equal(test_type, float64(1), summary["data"].(map[string]interface{})["broken_urls"])
+
+	// Commands for finished, unknown or other sessions' checks are answered without failing
+	// the session.
+	tests := []struct {
+		name          string
+		command       models.SessionCommand
+		expectedType  string
+		expectedError interface{}
+	}{
+		{
+			name: "Cancel Finished Check",
+			command: models.SessionCommand{Action: models.SessionCancel,
+				RequestID: requestID},
+			expectedType:  models.SessionError,
+			expectedError: "no URL check in progress for the request ID",
+		},
+		{
+			name: "Prioritise Finished Check",
+			command: models.SessionCommand{Action: models.SessionPrioritise,
+				RequestID: requestID, URLs: []string{"http://example.com/ok"}},
+			expectedType: models.SessionPrioritised,
+		},
+		{
+			name:          "Cancel Check Of Another Session",
+			command:       models.SessionCommand{Action: models.SessionCancel, RequestID: "id"},
+			expectedType:  models.SessionError,
+			expectedError: notSessionRequestMessage,
+		},
+		{
+			name: "Prioritise Check Of Another Session",
+			command: models.SessionCommand{Action: models.SessionPrioritise, RequestID: "id",
+				URLs: []string{"http://example.com/ok"}},
+			expectedType:  models.SessionError,
+			expectedError: notSessionRequestMessage,
+		},
+		{
+			name:          "Unknown Action",
+			command:       models.SessionCommand{Action: "unknown"},
+			expectedType:  models.SessionError,
+			expectedError: "unknown action: unknown",
+		},
+		{
+			name:          "Invalid URL",
+			command:       models.SessionCommand{Action: models.SessionScrape},
+			expectedType:  models.SessionError,
+			expectedError: "url query parameter is required",
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			assert.NoError(test_type, websocket.JSON.Send(conn, test_data.command))
+
+			message := receiveMessage(test_type, conn)
+			assert.Equal(test_type, test_data.expectedType, message["type"])
+			assert.Equal(test_type, test_data.expectedError, message["error"])
+		})
+	}
+}
+
+func TestWebSocketHandler_ForeignOrigin(test_type *testing.T) {
+	router := gin.Default()
+	router.GET("/ws", WebSocketHandler)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", "",
+		"http://attacker.example")
+	if conn != nil {
+		conn.Close()
+	}
+	assert.Error(test_type, err)
+}
+
+func TestWebSocketHandler_NoOrigin(test_type *testing.T) {
+	router := gin.Default()
+	router.GET("/ws", WebSocketHandler)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Handshake the way command line clients do, without an Origin header.
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/ws", nil)
+	assert.NoError(test_type, err)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(test_type, err)
+	defer resp.Body.Close()
+
+	assert.Equal(test_type, http.StatusSwitchingProtocols, resp.StatusCode)
+}
+
+func TestCheckOrigin(test_type *testing.T) {
+	tests := []struct {
+		name           string
+		origin         string
+		allowedOrigins string
+		expectedError  bool
+	}{
+		{
+			name:   "Same Origin",
+			origin: "http://localhost:8080",
+		},
+		{
+			name:          "Other Origin",
+			origin:        "http://attacker.example",
+			expectedError: true,
+		},
+		{
+			name:           "Allowed Origin",
+			origin:         "https://ui.example",
+			allowedOrigins: "http://other.example, https://ui.example/",
+		},
+		{
+			name:           "Allowed Host With Other Scheme",
+			origin:         "http://ui.example",
+			allowedOrigins: "https://ui.example",
+			expectedError:  true,
+		},
+		{
+			name:           "Any Origin",
+			origin:         "http://attacker.example",
+			allowedOrigins: "*",
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			origin, err := url.Parse(test_data.origin)
+			assert.NoError(test_type, err)
+
+			err = checkOrigin(origin, "localhost:8080", test_data.allowedOrigins)
+			assert.Equal(test_type, test_data.expectedError, err != nil)
+		})
+	}
+
+	assert.NoError(test_type, checkOrigin(nil, "localhost:8080", ""),
+		"Clients which are not browsers should be allowed without an origin")
+}
@@ -0,0 +1,264 @@
+// This is to check the status of all URLs found on a scraped page in the background.
+// URLs are checked in batches of the URL check page size and each batch is recorded in the
+// storage as soon as it is checked. Number of batches checked at the same time is bounded
+// across all pages by the configured background check concurrency.
+// Pending URLs can be added, reordered or dropped while the check is in progress.
+package jobs
+
+import (
+	"fmt"
+	"scraper/config"
+	"scraper/logger"
+	"scraper/models"
+	"scraper/services"
+	"scraper/storage"
+	"sync"
+)
+
+// Background check of a single page.
+type checkTask struct {
+	// URLs waiting for the check in the order they are going to be checked.
+	pending []models.URLStatus
+	// URLs which have ever been queued for the check, pending, being checked or checked.
+	queued    map[string]struct{}
+	cancelled bool
+}
+
+var checks = struct {
+	sync.Mutex
+	running     map[string]*checkTask
+	subscribers map[string]map[chan models.URLStatus]struct{}
+	slots       chan struct{}
+}{
+	running:     make(map[string]*checkTask),
+	subscribers: make(map[string]map[chan models.URLStatus]struct{}),
+}
+
+// This is to start checking all not yet checked URLs of the given resource types of the page
+// stored under the request ID. URLs of every type are checked if no types are given.
+// If URLs of the page are already being checked, URLs of the given types which are not queued
+// yet are added to the end of the running check instead of starting another one.
+// Returns false if there is no such page or its running check has been cancelled, otherwise
+// all URLs of the given types are checked before subscribers of the request ID are notified
+// of the end of the check.
+func CheckAllURLs(requestID string, resourceTypes []string) bool {
+	checks.Lock()
+	defer checks.Unlock()
+
+	pageInfo, exists := storage.RetrievePageInfo(requestID)
+	if !exists {
+		return false
+	}
+	urls := pendingURLs(services.FilterURLsByType(pageInfo.URLs, resourceTypes))
+
+	if task, running := checks.running[requestID]; running {
+		if task.cancelled {
+			return false
+		}
+		added := task.queue(urls)
+		logger.Debug(fmt.Sprintf("Added [%d] pending URLs to the background check of [%s]",
+			added, requestID))
+		return true
+	}
+
+	if checks.slots == nil {
+		checks.slots = make(chan struct{}, max(config.GetBackgroundCheckConcurrency(), 1))
+	}
+	task := &checkTask{queued: make(map[string]struct{}, len(urls))}
+	task.queue(urls)
+	checks.running[requestID] = task
+
+	logger.Debug(fmt.Sprintf("Checking [%d] pending URLs of [%s] in the background",
+		len(task.pending), requestID))
+	go checkAllURLs(requestID, task, checks.slots)
+	return true
+}
+
+// This is to add URLs which have not been queued before to the end of the task queue.
+// Returns the number of added URLs.
+func (task *checkTask) queue(urls []models.URLStatus) int {
+	added := 0
+	for _, urlStatus := range urls {
+		if _, found := task.queued[urlStatus.URL]; found {
+			continue
+		}
+		task.queued[urlStatus.URL] = struct{}{}
+		task.pending = append(task.pending, urlStatus)
+		added++
+	}
+	return added
+}
+
+// This is to check if URLs of the page stored under the request ID are being checked.
+func IsCheckingURLs(requestID string) bool {
+	checks.Lock()
+	defer checks.Unlock()
+
+	_, running := checks.running[requestID]
+	return running
+}
+
+// This is to cancel the background check of the page stored under the request ID.
+// URLs which are being checked at the moment are still recorded, rest are left unchecked.
+// Returns false if URLs of the page are not being checked.
+func CancelCheck(requestID string) bool {
+	checks.Lock()
+	defer checks.Unlock()
+
+	task, running := checks.running[requestID]
+	if !running {
+		return false
+	}
+	task.cancelled = true
+	task.pending = nil
+	return true
+}
+
+// This is to move the given URLs to the front of the background check queue of the page
+// stored under the request ID, in the given order. Returns the number of moved URLs.
+func PrioritiseURLs(requestID string, urls []string) int {
+	checks.Lock()
+	defer checks.Unlock()
+
+	task, running := checks.running[requestID]
+	if !running {
+		return 0
+	}
+
+	positions := make(map[string]int, len(task.pending))
+	for i, urlStatus := range task.pending {
+		positions[urlStatus.URL] = i
+	}
+
+	prioritised := make([]models.URLStatus, 0, len(task.pending))
+	moved := make(map[int]struct{}, len(urls))
+	for _, url := range urls {
+		position, found := positions[url]
+		if _, alreadyMoved := moved[position]; !found || alreadyMoved {
+			continue
+		}
+		moved[position] = struct{}{}
+		prioritised = append(prioritised, task.pending[position])
+	}
+	for i, urlStatus := range task.pending {
+		if _, alreadyMoved := moved[i]; !alreadyMoved {
+			prioritised = append(prioritised, urlStatus)
+		}
+	}
+	task.pending = prioritised
+	return len(moved)
+}
+
+// This is to subscribe to URL statuses of the page stored under the request ID.
+// Each URL status is delivered as soon as it is checked in the background, and the channel
+// is closed once the background check of the page finishes. Statuses which do not fit
+// into the given buffer size are dropped if the subscriber does not keep up.
+// Returned function cancels the subscription.
+func Subscribe(requestID string, buffer int) (<-chan models.URLStatus, func()) {
+	checks.Lock()
+	defer checks.Unlock()
+
+	events := make(chan models.URLStatus, buffer)
+	if checks.subscribers[requestID] == nil {
+		checks.subscribers[requestID] = make(map[chan models.URLStatus]struct{})
+	}
+	checks.subscribers[requestID][events] = struct{}{}
+
+	return events, func() {
+		checks.Lock()
+		defer checks.Unlock()
+
+		if _, subscribed := checks.subscribers[requestID][events]; subscribed {
+			delete(checks.subscribers[requestID], events)
+			close(events)
+		}
+		if len(checks.subscribers[requestID]) == 0 {
+			delete(checks.subscribers, requestID)
+		}
+	}
+}
+
+// This is to deliver the checked URL status to subscribers of the request ID.
+func publish(requestID string, urlStatus models.URLStatus) {
+	checks.Lock()
+	defer checks.Unlock()
+
+	for events := range checks.subscribers[requestID] {
+		select {
+		case events <- urlStatus:
+		default:
+			logger.Debug(fmt.Sprintf("Dropped URL status of [%s] for a slow subscriber", requestID))
+		}
+	}
+}
+
+// This is to mark the background check of the request ID as finished and notify subscribers.
+func finish(requestID string) {
+	checks.Lock()
+	defer checks.Unlock()
+
+	delete(checks.running, requestID)
+	for events := range checks.subscribers[requestID] {
+		close(events)
+	}
+	delete(checks.subscribers, requestID)
+}
+
+// This is to check all pending URLs batch by batch and record their statuses.
+func checkAllURLs(requestID string, task *checkTask, slots chan struct{}) {
+	defer finish(requestID)
+
+	client := services.NewURLCheckClient()
+	pageSize := max(config.GetURLCheckPageSize(), 1)
+	for {
+		slots <- struct{}{}
+		batch := nextBatch(task, pageSize)
+		if len(batch) == 0 {
+			<-slots
+			return
+		}
+		services.CheckURLStatusWithProgress(client, batch, 0, len(batch), func(idx int) {
+			publish(requestID, batch[idx])
+		})
+		<-slots
+
+		if !storage.UpdateURLStatuses(requestID, batch) {
+			// Page info has expired or has been removed in the meantime.
+			return
+		}
+	}
+}
+
+// This is to take the next batch of URLs to check from the front of the task queue.
+func nextBatch(task *checkTask, size int) []models.URLStatus {
+	checks.Lock()
+	defer checks.Unlock()
+
+	if task.cancelled {
+		return nil
+	}
+	size = min(size, len(task.pending))
+	batch := task.pending[:size:size]
+	task.pending = task.pending[size:]
+	return batch
+}
+
+// This is to collect URLs which do not have a recorded status yet.
+// Each URL is collected once even if it is referenced as more than one resource type, as
+// the status of a URL does not depend on what refers to it and recorded statuses apply to
+// every reference of the URL. The type it is first found as is kept.
+func pendingURLs(urls []models.URLStatus) []models.URLStatus {
+	seen := make(map[string]struct{}, len(urls))
+	var pending []models.URLStatus
+	for _, urlStatus := range urls {
+		if _, found := seen[urlStatus.URL]; found || urlStatus.CheckedAt != nil {
+			continue
+		}
+		seen[urlStatus.URL] = struct{}{}
+		pending = append(pending, models.URLStatus{
+			URL:          urlStatus.URL,
+			ResourceType: urlStatus.ResourceType,
+		})
+	}
+	return pending
+}
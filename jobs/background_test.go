@@ -0,0 +1,242 @@
+package jobs
+
+import (
+	"fmt"
+	"net/http"
+	"scraper/config"
+	"scraper/models"
+	"scraper/services"
+	"scraper/storage"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+)
+
+// This is to wait until background checks of the request ID finish or the timeout exceeds.
+func waitForChecks(test_type *testing.T, requestID string) {
+	deadline := time.Now().Add(2 * time.Second)
+	for IsCheckingURLs(requestID) {
+		if time.Now().After(deadline) {
+			test_type.Fatalf("Background checks of [%s] did not finish in time", requestID)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCheckAllURLs(test_type *testing.T) {
+	checkedAt := time.Now()
+	requestID, err := storage.StorePageInfo(&models.PageInfo{URLs: []models.URLStatus{
+		{URL: "http://example.com/checked", HTTPStatus: 500, CheckedAt: &checkedAt},
+		{URL: "http://example.com/ok"},
+		{URL: "http://example.com/broken"},
+		{URL: "http://example.com/ok"},
+	}})
+	assert.NoError(test_type, err)
+
+	release := make(chan struct{})
+	var checkedURLs []string
+	patchCheckURLStatus := monkey.Patch(services.CheckURLStatusWithProgress,
+		func(client *http.Client, urls []models.URLStatus, start, end int,
+			onChecked func(idx int)) int {
+			<-release
+			now := time.Now()
+			for i := start; i < end; i++ {
+				checkedURLs = append(checkedURLs, urls[i].URL)
+				urls[i].HTTPStatus = http.StatusOK
+				if urls[i].URL == "http://example.com/broken" {
+					urls[i].HTTPStatus = http.StatusNotFound
+				}
+				urls[i].CheckedAt = &now
+				onChecked(i)
+			}
+			return 0
+		})
+	defer patchCheckURLStatus.Unpatch()
+
+	events, unsubscribe := Subscribe(requestID, 10)
+	defer unsubscribe()
+
+	assert.True(test_type, CheckAllURLs(requestID, nil), "Background check should start")
+	assert.True(test_type, IsCheckingURLs(requestID), "Background check should be running")
+	assert.True(test_type, CheckAllURLs(requestID, nil),
+		"Background check should be joined instead of started twice for the same page")
+
+	close(release)
+	waitForChecks(test_type, requestID)
+
+	var publishedURLs []string
+	for urlStatus := range events {
+		publishedURLs = append(publishedURLs, urlStatus.URL)
+	}
+	assert.ElementsMatch(test_type,
+		[]string{"http://example.com/ok", "http://example.com/broken"}, publishedURLs,
+		"Each checked URL should be published and the channel closed at the end")
+
+	assert.ElementsMatch(test_type,
+		[]string{"http://example.com/ok", "http://example.com/broken"}, checkedURLs,
+		"Only pending URLs should be checked, once each")
+
+	pageInfo, _ := storage.RetrievePageInfo(requestID)
+	accessible, inaccessible, pending := services.SummarizeURLStatus(pageInfo.URLs)
+	assert.Equal(test_type, 2, accessible)
+	assert.Equal(test_type, 2, inaccessible)
+	assert.Equal(test_type, 0, pending)
+}
+
+func TestCheckAllURLs_ResourceTypes(test_type *testing.T) {
+	requestID, err := storage.StorePageInfo(&models.PageInfo{URLs: []models.URLStatus{
+		{URL: "http://example.com/page", ResourceType: models.ResourceAnchor},
+		{URL: "http://example.com/logo.png", ResourceType: models.ResourceImage},
+		{URL: "http://example.com/page", ResourceType: models.ResourceImage},
+		{URL: "http://example.com/app.js", ResourceType: models.ResourceScript},
+		{URL: "http://example.com/legacy"},
+	}})
+	assert.NoError(test_type, err)
+
+	var checked []models.URLStatus
+	patchCheckURLStatus := monkey.Patch(services.CheckURLStatusWithProgress,
+		func(client *http.Client, urls []models.URLStatus, start, end int,
+			onChecked func(idx int)) int {
+			now := time.Now()
+			for i := start; i < end; i++ {
+				checked = append(checked, urls[i])
+				urls[i].HTTPStatus = http.StatusOK
+				urls[i].CheckedAt = &now
+			}
+			return 0
+		})
+	defer patchCheckURLStatus.Unpatch()
+
+	assert.True(test_type, CheckAllURLs(requestID, services.DefaultResourceTypes))
+	waitForChecks(test_type, requestID)
+
+	var checkedURLs []string
+	for _, urlStatus := range checked {
+		checkedURLs = append(checkedURLs, urlStatus.ResourceType+" "+urlStatus.URL)
+	}
+	assert.Equal(test_type, []string{"anchor http://example.com/page", " http://example.com/legacy"},
+		checkedURLs, "Only links should be checked, keeping their resource type")
+
+	pageInfo, _ := storage.RetrievePageInfo(requestID)
+	_, _, pending := services.SummarizeURLStatus(pageInfo.URLs)
+	assert.Equal(test_type, 2, pending,
+		"Image and script URLs which are not also links should be left unchecked")
+}
+
+func TestCheckAllURLs_AddTypesToRunningCheck(test_type *testing.T) {
+	requestID, err := storage.StorePageInfo(&models.PageInfo{URLs: []models.URLStatus{
+		{URL: "http://example.com/page", ResourceType: models.ResourceAnchor},
+		{URL: "http://example.com/logo.png", ResourceType: models.ResourceImage},
+		{URL: "http://example.com/logo.png", ResourceType: models.ResourceAnchor},
+	}})
+	assert.NoError(test_type, err)
+
+	checking := make(chan struct{}, 2)
+	release := make(chan struct{})
+	var checkedURLs []string
+	patchCheckURLStatus := monkey.Patch(services.CheckURLStatusWithProgress,
+		func(client *http.Client, urls []models.URLStatus, start, end int,
+			onChecked func(idx int)) int {
+			checking <- struct{}{}
+			<-release
+			now := time.Now()
+			for i := start; i < end; i++ {
+				checkedURLs = append(checkedURLs, urls[i].URL)
+				urls[i].CheckedAt = &now
+			}
+			return 0
+		})
+	defer patchCheckURLStatus.Unpatch()
+
+	assert.True(test_type, CheckAllURLs(requestID, []string{models.ResourceImage}))
+	// Links are requested while the images are being checked.
+	<-checking
+	assert.True(test_type, CheckAllURLs(requestID, []string{models.ResourceAnchor}))
+	close(release)
+	waitForChecks(test_type, requestID)
+
+	assert.ElementsMatch(test_type,
+		[]string{"http://example.com/logo.png", "http://example.com/page"}, checkedURLs,
+		"URLs of both requests should be checked, once each")
+	assert.Len(test_type, checking, 1, "Added URLs should be checked in a later batch")
+
+	pageInfo, _ := storage.RetrievePageInfo(requestID)
+	_, _, pending := services.SummarizeURLStatus(pageInfo.URLs)
+	assert.Equal(test_type, 0, pending)
+}
+
+func TestCheckAllURLs_NotFound(test_type *testing.T) {
+	assert.False(test_type, CheckAllURLs("nonexistent-id", nil),
+		"Background check should not start for a non-existent page")
+	assert.False(test_type, IsCheckingURLs("nonexistent-id"))
+}
+
+func TestPrioritiseAndCancelCheck(test_type *testing.T) {
+	pageInfo := &models.PageInfo{}
+	for i := 0; i < 3*config.GetURLCheckPageSize(); i++ {
+		pageInfo.URLs = append(pageInfo.URLs,
+			models.URLStatus{URL: fmt.Sprintf("http://example.com/%d", i)})
+	}
+	requestID, err := storage.StorePageInfo(pageInfo)
+	assert.NoError(test_type, err)
+	lastURL := pageInfo.URLs[len(pageInfo.URLs)-1].URL
+
+	checking := make(chan struct{})
+	release := make(chan struct{})
+	var batches [][]string
+	patchCheckURLStatus := monkey.Patch(services.CheckURLStatusWithProgress,
+		func(client *http.Client, urls []models.URLStatus, start, end int,
+			onChecked func(idx int)) int {
+			checking <- struct{}{}
+			<-release
+			var batch []string
+			now := time.Now()
+			for i := start; i < end; i++ {
+				batch = append(batch, urls[i].URL)
+				urls[i].CheckedAt = &now
+			}
+			batches = append(batches, batch)
+			return 0
+		})
+	defer patchCheckURLStatus.Unpatch()
+
+	assert.True(test_type, CheckAllURLs(requestID, nil))
+	// Wait until the first batch is being checked, then move the last URL to the front.
+	<-checking
+	assert.Equal(test_type, 1, PrioritiseURLs(requestID, []string{lastURL, "http://unknown"}))
+	release <- struct{}{}
+
+	// Cancel while the second batch is being checked.
+	<-checking
+	assert.True(test_type, CancelCheck(requestID))
+	release <- struct{}{}
+	waitForChecks(test_type, requestID)
+
+	assert.Len(test_type, batches, 2, "No batch should be checked after cancelling")
+	assert.Equal(test_type, lastURL, batches[1][0], "Prioritised URL should be checked first")
+	assert.False(test_type, CancelCheck(requestID), "Finished check should not be cancellable")
+	assert.Equal(test_type, 0, PrioritiseURLs(requestID, []string{lastURL}))
+
+	stored, _ := storage.RetrievePageInfo(requestID)
+	_, _, pending := services.SummarizeURLStatus(stored.URLs)
+	assert.Equal(test_type, len(stored.URLs)-len(batches[0])-len(batches[1]), pending,
+		"Checked batches should be recorded and the rest left pending")
+}
+
+func TestSubscribe_Unsubscribe(test_type *testing.T) {
+	events, unsubscribe := Subscribe("mockRequestID", 1)
+
+	publish("mockRequestID", models.URLStatus{URL: "http://example.com"})
+	publish("mockRequestID", models.URLStatus{URL: "http://example.com/dropped"})
+	unsubscribe()
+	unsubscribe()
+
+	var received []models.URLStatus
+	for urlStatus := range events {
+		received = append(received, urlStatus)
+	}
+	assert.Equal(test_type, []models.URLStatus{{URL: "http://example.com"}}, received,
+		"Statuses beyond the buffer should be dropped and the channel closed on unsubscribe")
+}
@@ -0,0 +1,173 @@
+// This is an in-memory job queue to scrape pages asynchronously.
+// Submitted jobs are picked by a fixed size worker pool which fetches and parses the page,
+// stores the page info and checks the status of the first page of URLs in the background.
+// Job state and progress can be polled by the job ID until the job is removed after the
+// configured retention period.
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"scraper/config"
+	"scraper/logger"
+	"scraper/models"
+	"scraper/services"
+	"scraper/storage"
+	"scraper/utils"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotStarted = errors.New("job workers are not started")
+	ErrQueueFull  = errors.New("job queue is full")
+)
+
+var registry = struct {
+	sync.RWMutex
+	jobs  map[string]*models.Job
+	queue chan string
+}{jobs: make(map[string]*models.Job)}
+
+// This is to start the worker pool which executes submitted jobs.
+func Start(workers, queueSize int) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.queue != nil {
+		return
+	}
+	registry.queue = make(chan string, queueSize)
+	for i := 0; i < workers; i++ {
+		go work(registry.queue)
+	}
+}
+
+// This is to submit a job to scrape the given URL.
+func Submit(url string) (models.Job, error) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.queue == nil {
+		return models.Job{}, ErrNotStarted
+	}
+	removeFinished(time.Duration(config.GetJobRetention()) * time.Second)
+
+	now := time.Now()
+	job := &models.Job{
+		ID:        storage.GenerateID(),
+		URL:       url,
+		State:     models.JobQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	select {
+	case registry.queue <- job.ID:
+		registry.jobs[job.ID] = job
+		return *job, nil
+	default:
+		return models.Job{}, ErrQueueFull
+	}
+}
+
+// This is to get the current state of the job by its ID.
+func Get(id string) (models.Job, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	job, exists := registry.jobs[id]
+	if !exists {
+		return models.Job{}, false
+	}
+	return *job, true
+}
+
+// This is to execute jobs received from the queue one by one.
+func work(queue <-chan string) {
+	for id := range queue {
+		job, exists := Get(id)
+		if exists {
+			run(job)
+		}
+	}
+}
+
+// This is to scrape the page of the given job while tracking its state and progress.
+func run(job models.Job) {
+	client := services.NewScrapeClient()
+
+	update(job.ID, func(job *models.Job) { job.State = models.JobFetching })
+	resp, err := services.FetchPage(client, job.URL)
+	if err != nil {
+		fail(job.ID, err)
+		return
+	}
+
+	update(job.ID, func(job *models.Job) { job.State = models.JobParsing })
+	pageInfo, err := services.ParsePage(resp, job.URL, services.ParseOptions{})
+	resp.Body.Close()
+	if err != nil {
+		fail(job.ID, err)
+		return
+	}
+
+	requestID, err := storage.StorePageInfo(pageInfo)
+	if err != nil {
+		fail(job.ID, err)
+		return
+	}
+	// Result of the job covers the links, the same as a scrape request without types does.
+	pageInfo.URLs = services.FilterURLsByType(pageInfo.URLs, services.DefaultResourceTypes)
+	end := min(config.GetURLCheckPageSize(), len(pageInfo.URLs))
+	update(job.ID, func(job *models.Job) {
+		job.State = models.JobChecking
+		job.Progress.URLsFound = len(pageInfo.URLs)
+		job.Progress.URLsToCheck = end
+	})
+
+	inaccessibleCount := services.CheckURLStatusWithProgress(client, pageInfo.URLs, 0, end,
+		func(idx int) {
+			update(job.ID, func(job *models.Job) { job.Progress.URLsChecked++ })
+		})
+	storage.UpdateURLStatuses(requestID, pageInfo.URLs[:end])
+
+	totalPages := utils.CalculateTotalPages(len(pageInfo.URLs), config.GetURLCheckPageSize())
+	result := utils.BuildPageResponse(requestID, 1, totalPages, pageInfo,
+		inaccessibleCount, 0, end, "")
+	update(job.ID, func(job *models.Job) {
+		job.State = models.JobDone
+		job.Progress.InaccessibleURLs = inaccessibleCount
+		job.Result = &result
+	})
+}
+
+// This is to mark the job as failed with the given error.
+func fail(id string, err error) {
+	logger.Error(fmt.Sprintf("Job [%s] failed: %v", id, err))
+	update(id, func(job *models.Job) {
+		job.State = models.JobFailed
+		job.Error = err.Error()
+	})
+}
+
+// This is to apply the given change to the job stored under the given ID.
+func update(id string, change func(job *models.Job)) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if job, exists := registry.jobs[id]; exists {
+		change(job)
+		job.UpdatedAt = time.Now()
+	}
+}
+
+// This is to remove finished jobs which are older than the given retention period.
+// Caller must hold the registry lock.
+func removeFinished(retention time.Duration) {
+	for id, job := range registry.jobs {
+		if job.IsFinished() && time.Since(job.UpdatedAt) > retention {
+			delete(registry.jobs, id)
+		}
+	}
+}
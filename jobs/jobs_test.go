@@ -0,0 +1,151 @@
+package jobs
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"scraper/models"
+	"scraper/services"
+	"scraper/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+)
+
+// This is to wait until the job finishes or the timeout exceeds.
+func waitForJob(test_type *testing.T, id string) models.Job {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, exists := Get(id)
+		assert.True(test_type, exists, "Submitted job should exist")
+		if job.IsFinished() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	test_type.Fatalf("Job [%s] did not finish in time", id)
+	return models.Job{}
+}
+
+// This is to run the test with a dedicated job registry.
+func withRegistry(test_type *testing.T, queue chan string) {
+	registry.Lock()
+	previousJobs, previousQueue := registry.jobs, registry.queue
+	registry.jobs, registry.queue = make(map[string]*models.Job), queue
+	registry.Unlock()
+
+	test_type.Cleanup(func() {
+		registry.Lock()
+		registry.jobs, registry.queue = previousJobs, previousQueue
+		registry.Unlock()
+	})
+}
+
+func TestSubmit(test_type *testing.T) {
+	withRegistry(test_type, nil)
+	Start(2, 10)
+
+	tests := []struct {
+		name          string
+		mockBody      string
+		mockError     error
+		expectedState models.JobState
+		expectedError string
+	}{
+		{
+			name: "Successful Job",
+			mockBody: `<html><head><title>Sample Page</title></head><body>
+				<a href="/first">First</a><a href="/second">Second</a></body></html>`,
+			expectedState: models.JobDone,
+		},
+		{
+			name:          "Failed Job",
+			mockError:     errors.New("mocked error"),
+			expectedState: models.JobFailed,
+			expectedError: "mocked error",
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			patchFetchPage := monkey.Patch(services.FetchPage,
+				func(client *http.Client, url string) (*http.Response, error) {
+					if test_data.mockError != nil {
+						return nil, test_data.mockError
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(test_data.mockBody)),
+					}, nil
+				})
+			defer patchFetchPage.Unpatch()
+
+			patchCheckURLStatus := monkey.Patch(services.CheckURLStatusWithProgress,
+				func(client *http.Client, urls []models.URLStatus, start, end int,
+					onChecked func(idx int)) int {
+					for i := start; i < end; i++ {
+						urls[i].HTTPStatus = http.StatusNotFound
+						onChecked(i)
+					}
+					return end - start
+				})
+			defer patchCheckURLStatus.Unpatch()
+
+			submitted, err := Submit("http://example.com")
+			assert.NoError(test_type, err)
+			assert.Equal(test_type, models.JobQueued, submitted.State)
+
+			job := waitForJob(test_type, submitted.ID)
+			assert.Equal(test_type, test_data.expectedState, job.State)
+			assert.Equal(test_type, test_data.expectedError, job.Error)
+
+			if test_data.expectedState == models.JobDone {
+				assert.Equal(test_type, 2, job.Progress.URLsFound)
+				assert.Equal(test_type, 2, job.Progress.URLsChecked)
+				assert.Equal(test_type, 2, job.Progress.InaccessibleURLs)
+				assert.Equal(test_type, "Sample Page", job.Result.Scraped.Title)
+
+				_, exists := storage.RetrievePageInfo(job.Result.RequestID)
+				assert.True(test_type, exists, "Scraped page info should be stored")
+			} else {
+				assert.Nil(test_type, job.Result)
+			}
+		})
+	}
+}
+
+func TestSubmit_NotStarted(test_type *testing.T) {
+	withRegistry(test_type, nil)
+
+	_, err := Submit("http://example.com")
+	assert.ErrorIs(test_type, err, ErrNotStarted)
+}
+
+func TestSubmit_QueueFull(test_type *testing.T) {
+	// Unbuffered queue without workers can not accept any job.
+	withRegistry(test_type, make(chan string))
+
+	_, err := Submit("http://example.com")
+	assert.ErrorIs(test_type, err, ErrQueueFull)
+}
+
+func TestRemoveFinished(test_type *testing.T) {
+	withRegistry(test_type, nil)
+	registry.jobs["old"] = &models.Job{State: models.JobDone,
+		UpdatedAt: time.Now().Add(-2 * time.Hour)}
+	registry.jobs["running"] = &models.Job{State: models.JobChecking,
+		UpdatedAt: time.Now().Add(-2 * time.Hour)}
+	registry.jobs["recent"] = &models.Job{State: models.JobFailed, UpdatedAt: time.Now()}
+
+	removeFinished(time.Hour)
+
+	_, exists := Get("old")
+	assert.False(test_type, exists, "Old finished job should be removed")
+	_, exists = Get("running")
+	assert.True(test_type, exists, "Running job should be kept")
+	_, exists = Get("recent")
+	assert.True(test_type, exists, "Recently finished job should be kept")
+}
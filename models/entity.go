@@ -1,17 +1,194 @@
 package models
 
+import "time"
+
 type PageInfo struct {
-	HTMLVersion       string         `json:"html_version"`
-	Title             string         `json:"title"`
-	HeadingCounts     map[string]int `json:"heading_counts"`
-	URLs              []URLStatus    `json:"urls"`
-	InternalURLsCount int            `json:"internal_urls_count"`
-	ExternalURLsCount int            `json:"external_urls_count"`
-	ContainsLoginForm bool           `json:"contains_login_form"`
+	HTMLVersion       string                 `json:"html_version"`
+	RenderingMode     string                 `json:"rendering_mode"`
+	IsXHTML           bool                   `json:"is_xhtml"`
+	Encoding          string                 `json:"encoding,omitempty"`
+	Title             string                 `json:"title"`
+	HeadingCounts     map[string]int         `json:"heading_counts"`
+	HeadingOutline    []Heading              `json:"heading_outline"`
+	HeadingIssues     []HeadingIssue         `json:"heading_issues"`
+	URLs              []URLStatus            `json:"urls"`
+	InternalURLsCount int                    `json:"internal_urls_count"`
+	ExternalURLsCount int                    `json:"external_urls_count"`
+	ResourceCounts    map[string]int         `json:"resource_counts"`
+	SchemeCounts      map[string]int         `json:"scheme_counts"`
+	ContainsLoginForm bool                   `json:"contains_login_form"`
+	Forms             []FormInfo             `json:"forms"`
+	Accessibility     []AccessibilityFinding `json:"accessibility"`
+	Content           *PageContent           `json:"content,omitempty"`
+	Metadata          PageMetadata           `json:"metadata"`
+	StructuredData    StructuredData         `json:"structured_data"`
+}
+
+type Heading struct {
+	Level    int       `json:"level"`
+	Text     string    `json:"text"`
+	Children []Heading `json:"children,omitempty"`
+}
+
+// An accessibility issue of the heading outline. Position is the 1-based order of the
+// offending heading on the page, and is left out for issues of the page as a whole.
+type HeadingIssue struct {
+	Type     string `json:"type"`
+	Position int    `json:"position,omitempty"`
+	Level    int    `json:"level,omitempty"`
+	Message  string `json:"message"`
+}
+
+// SEO related metadata declared by the page.
+type PageMetadata struct {
+	Description string              `json:"description,omitempty"`
+	Robots      string              `json:"robots,omitempty"`
+	Canonical   string              `json:"canonical,omitempty"`
+	Alternates  []HreflangAlternate `json:"hreflang_alternates,omitempty"`
+	OpenGraph   map[string]string   `json:"open_graph,omitempty"`
+	TwitterCard map[string]string   `json:"twitter_card,omitempty"`
+	Viewport    string              `json:"viewport,omitempty"`
+	Charset     string              `json:"charset,omitempty"`
+}
+
+type HreflangAlternate struct {
+	Hreflang string `json:"hreflang"`
+	URL      string `json:"url"`
+}
+
+// Structured data embedded into the page, normalised regardless of its format.
+type StructuredData struct {
+	Entities []StructuredEntity    `json:"entities"`
+	Errors   []StructuredDataError `json:"errors,omitempty"`
+}
+
+// An entity described by structured data. Property values are strings, numbers, booleans,
+// nested entities or lists of them.
+type StructuredEntity struct {
+	Format     string                 `json:"format"`
+	Types      []string               `json:"types"`
+	ID         string                 `json:"id,omitempty"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type StructuredDataError struct {
+	Format  string `json:"format"`
+	Block   int    `json:"block,omitempty"`
+	Message string `json:"message"`
+}
+
+// A form found on the page. Fields which do not belong to any form are reported together as
+// a formless one. Confidence tells how certain the classification is, from 0 to 1.
+type FormInfo struct {
+	Action          string      `json:"action,omitempty"`
+	Method          string      `json:"method"`
+	Formless        bool        `json:"formless,omitempty"`
+	Fields          []FormField `json:"fields"`
+	HasCSRFToken    bool        `json:"has_csrf_token"`
+	SubmitsOverHTTP bool        `json:"submits_over_http"`
+	Classification  string      `json:"classification"`
+	Confidence      float64     `json:"confidence"`
+}
+
+// Main content of the page, extracted only if asked for. Word count covers all visible text
+// of the page, while the reading time covers the main text.
+type PageContent struct {
+	MainText           string `json:"main_text"`
+	WordCount          int    `json:"word_count"`
+	Language           string `json:"language,omitempty"`
+	ReadingTimeMinutes int    `json:"reading_time_minutes"`
+}
+
+// A violation of an accessibility check. Path locates the offending element in the page.
+type AccessibilityFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+}
+
+type FormField struct {
+	Name         string `json:"name,omitempty"`
+	Type         string `json:"type"`
+	Autocomplete string `json:"autocomplete,omitempty"`
 }
 
 type URLStatus struct {
+	URL string `json:"url"`
+	// Type of the referenced resource, and the elements and attributes it is referenced by.
+	ResourceType string   `json:"resource_type"`
+	Origins      []string `json:"origins,omitempty"`
+	// Number of times the URL appears on the page, and the distinct texts it is linked with.
+	Occurrences int      `json:"occurrences,omitempty"`
+	AnchorTexts []string `json:"anchor_texts,omitempty"`
+	// Result of the latest status check.
+	HTTPStatus int        `json:"http_status"`
+	Error      string     `json:"error"`
+	ErrorCode  ErrorCode  `json:"error_code,omitempty"`
+	CheckedAt  *time.Time `json:"checked_at,omitempty"`
+	LatencyMs  int64      `json:"latency_ms,omitempty"`
+	Method     string     `json:"method,omitempty"`
+	Attempts   int        `json:"attempts,omitempty"`
+	// Redirect chain followed to reach the final status.
+	Redirects           []RedirectHop `json:"redirects,omitempty"`
+	FinalURL            string        `json:"final_url,omitempty"`
+	RedirectLoop        bool          `json:"redirect_loop,omitempty"`
+	CrossDomainRedirect bool          `json:"cross_domain_redirect,omitempty"`
+	InsecureRedirect    bool          `json:"insecure_redirect,omitempty"`
+}
+
+// Types of resources referenced by a page.
+const (
+	ResourceAnchor     = "anchor"
+	ResourceImage      = "image"
+	ResourceStylesheet = "stylesheet"
+	ResourceScript     = "script"
+	ResourceFrame      = "iframe"
+	ResourceMedia      = "media"
+	ResourceObject     = "object"
+	ResourceLink       = "link"
+	ResourceForm       = "form"
+)
+
+// All types of resources referenced by a page.
+var ResourceTypes = []string{ResourceAnchor, ResourceImage, ResourceStylesheet, ResourceScript,
+	ResourceFrame, ResourceMedia, ResourceObject, ResourceLink, ResourceForm}
+
+// Machine readable reason of a failed URL status check.
+type ErrorCode string
+
+const (
+	ErrorDNSFailure        ErrorCode = "dns_failure"
+	ErrorConnectionRefused ErrorCode = "connection_refused"
+	ErrorTimeout           ErrorCode = "timeout"
+	ErrorTLS               ErrorCode = "tls_error"
+	ErrorTooManyRedirects  ErrorCode = "too_many_redirects"
+	ErrorInvalidURL        ErrorCode = "invalid_url"
+	ErrorBlockedByPolicy   ErrorCode = "blocked_by_policy"
+	ErrorHTTP4xx           ErrorCode = "http_4xx"
+	ErrorHTTP5xx           ErrorCode = "http_5xx"
+	ErrorNetwork           ErrorCode = "network_error"
+)
+
+type RedirectHop struct {
 	URL        string `json:"url"`
 	HTTPStatus int    `json:"http_status"`
-	Error      string `json:"error"`
+	Location   string `json:"location"`
+}
+
+// This is to copy the result of a status check from the given URL status.
+// Information about the URL itself is left as it is.
+func (urlStatus *URLStatus) SetCheckResult(checked URLStatus) {
+	urlStatus.HTTPStatus = checked.HTTPStatus
+	urlStatus.Error = checked.Error
+	urlStatus.ErrorCode = checked.ErrorCode
+	urlStatus.CheckedAt = checked.CheckedAt
+	urlStatus.LatencyMs = checked.LatencyMs
+	urlStatus.Method = checked.Method
+	urlStatus.Attempts = checked.Attempts
+	urlStatus.Redirects = checked.Redirects
+	urlStatus.FinalURL = checked.FinalURL
+	urlStatus.RedirectLoop = checked.RedirectLoop
+	urlStatus.CrossDomainRedirect = checked.CrossDomainRedirect
+	urlStatus.InsecureRedirect = checked.InsecureRedirect
 }
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type JobState string
+
+const (
+	JobQueued   JobState = "queued"
+	JobFetching JobState = "fetching"
+	JobParsing  JobState = "parsing"
+	JobChecking JobState = "checking"
+	JobDone     JobState = "done"
+	JobFailed   JobState = "failed"
+)
+
+type JobProgress struct {
+	URLsFound        int `json:"urls_found"`
+	URLsToCheck      int `json:"urls_to_check"`
+	URLsChecked      int `json:"urls_checked"`
+	InaccessibleURLs int `json:"inaccessible_urls"`
+}
+
+type Job struct {
+	ID        string        `json:"job_id"`
+	URL       string        `json:"url"`
+	State     JobState      `json:"state"`
+	Progress  JobProgress   `json:"progress"`
+	Result    *PageResponse `json:"result,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// This is to check if the job has finished either successfully or not.
+func (job *Job) IsFinished() bool {
+	return job.State == JobDone || job.State == JobFailed
+}
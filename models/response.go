@@ -9,14 +9,26 @@ type Pagination struct {
 }
 
 type ScrapedData struct {
-	HTMLVersion       string         `json:"html_version"`
-	Title             string         `json:"title"`
-	Headings          map[string]int `json:"headings"`
-	ContainsLoginForm bool           `json:"contains_login_form"`
-	TotalURLs         int            `json:"total_urls"`
-	InternalURLs      int            `json:"internal_urls"`
-	ExternalURLs      int            `json:"external_urls"`
-	Paginated         PaginatedURLs  `json:"paginated"`
+	HTMLVersion       string                 `json:"html_version"`
+	RenderingMode     string                 `json:"rendering_mode"`
+	IsXHTML           bool                   `json:"is_xhtml"`
+	Encoding          string                 `json:"encoding,omitempty"`
+	Title             string                 `json:"title"`
+	Headings          map[string]int         `json:"headings"`
+	HeadingOutline    []Heading              `json:"heading_outline"`
+	HeadingIssues     []HeadingIssue         `json:"heading_issues"`
+	ContainsLoginForm bool                   `json:"contains_login_form"`
+	Forms             []FormInfo             `json:"forms"`
+	Accessibility     []AccessibilityFinding `json:"accessibility"`
+	Content           *PageContent           `json:"content,omitempty"`
+	TotalURLs         int                    `json:"total_urls"`
+	InternalURLs      int                    `json:"internal_urls"`
+	ExternalURLs      int                    `json:"external_urls"`
+	SchemeCounts      map[string]int         `json:"scheme_counts"`
+	ResourceCounts    map[string]int         `json:"resource_counts"`
+	Metadata          PageMetadata           `json:"metadata"`
+	StructuredData    StructuredData         `json:"structured_data"`
+	Paginated         PaginatedURLs          `json:"paginated"`
 }
 
 type PaginatedURLs struct {
@@ -29,3 +41,12 @@ type PageResponse struct {
 	Pagination Pagination  `json:"pagination"`
 	Scraped    ScrapedData `json:"scraped"`
 }
+
+type CheckSummary struct {
+	RequestID   string `json:"request_id"`
+	TotalURLs   int    `json:"total_urls"`
+	OKURLs      int    `json:"ok_urls"`
+	BrokenURLs  int    `json:"broken_urls"`
+	PendingURLs int    `json:"pending_urls"`
+	Checking    bool   `json:"checking"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// Actions a client can send over an interactive scrape session.
+const (
+	SessionScrape     = "scrape"
+	SessionCancel     = "cancel"
+	SessionPrioritise = "prioritise"
+)
+
+// Types of messages sent back to the client over an interactive scrape session.
+const (
+	SessionScraped     = "scraped"
+	SessionURLStatus   = "url_status"
+	SessionSummary     = "summary"
+	SessionCancelled   = "cancelled"
+	SessionPrioritised = "prioritised"
+	SessionError       = "error"
+)
+
+type SessionCommand struct {
+	Action    string   `json:"action"`
+	URL       string   `json:"url,omitempty"`
+	RequestID string   `json:"request_id,omitempty"`
+	URLs      []string `json:"urls,omitempty"`
+}
+
+type SessionMessage struct {
+	Type      string      `json:"type"`
+	RequestID string      `json:"request_id,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
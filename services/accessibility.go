@@ -0,0 +1,202 @@
+package services
+
+import (
+	"fmt"
+	"scraper/models"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Rules of the accessibility audit.
+const (
+	AccessibilityImageAlt    = "image_alt"
+	AccessibilityLinkName    = "link_name"
+	AccessibilityLabel       = "label"
+	AccessibilityHTMLLang    = "html_lang"
+	AccessibilityDuplicateID = "duplicate_id"
+	AccessibilityButtonName  = "button_name"
+)
+
+// Severities of accessibility findings, from the most to the least impact on users.
+const (
+	SeverityCritical = "critical"
+	SeveritySerious  = "serious"
+	SeverityMinor    = "minor"
+)
+
+// Audit of the page against basic WCAG checks, fed with elements as the page is traversed.
+type accessibilityAudit struct {
+	findings []pendingFinding
+	ids      map[string]int
+	labelFor map[string]bool
+}
+
+// A finding which is dropped if a label for the element turns up later in the page.
+type pendingFinding struct {
+	finding models.AccessibilityFinding
+	labelID string
+}
+
+func newAccessibilityAudit() *accessibilityAudit {
+	return &accessibilityAudit{ids: make(map[string]int), labelFor: make(map[string]bool)}
+}
+
+// This is to check the element against the audit rules.
+func (audit *accessibilityAudit) visit(node *html.Node) {
+	if node.Type != html.ElementNode {
+		return
+	}
+
+	if id := getAttr(node, "id"); id != "" {
+		audit.ids[id]++
+		if audit.ids[id] == 2 {
+			audit.add(node, AccessibilityDuplicateID, SeverityMinor,
+				fmt.Sprintf("id %q is used by more than one element", id))
+		}
+	}
+
+	switch node.Data {
+	case "html":
+		if strings.TrimSpace(getAttr(node, "lang")) == "" &&
+			strings.TrimSpace(getAttr(node, "xml:lang")) == "" {
+			audit.add(node, AccessibilityHTMLLang, SeveritySerious, "html element has no lang")
+		}
+	case "img":
+		if !hasAttr(node, "alt") && !isHiddenFromAssistiveTech(node) {
+			audit.add(node, AccessibilityImageAlt, SeverityCritical, "image has no alt text")
+		}
+	case "a":
+		if hasAttr(node, "href") && !isHiddenFromAssistiveTech(node) && !hasAccessibleName(node) {
+			audit.add(node, AccessibilityLinkName, SeveritySerious, "link has no discernible text")
+		}
+	case "button":
+		if !isHiddenFromAssistiveTech(node) && !hasAccessibleName(node) {
+			audit.add(node, AccessibilityButtonName, SeverityCritical, "button has no text")
+		}
+	case "label":
+		if target := getAttr(node, "for"); target != "" {
+			audit.labelFor[target] = true
+		}
+	case "input", "select", "textarea":
+		audit.visitControl(node)
+	}
+}
+
+// This is to check that the form control can be told apart by assistive technologies.
+func (audit *accessibilityAudit) visitControl(node *html.Node) {
+	inputType := strings.ToLower(strings.TrimSpace(getAttr(node, "type")))
+	if node.Data != "input" {
+		inputType = node.Data
+	}
+
+	switch inputType {
+	case "hidden", "submit", "reset":
+		// Hidden inputs are not shown, and submit and reset buttons have a default text.
+	case "image":
+		if strings.TrimSpace(getAttr(node, "alt")) == "" && !hasAccessibleName(node) {
+			audit.add(node, AccessibilityImageAlt, SeverityCritical, "image button has no alt text")
+		}
+	case "button":
+		if strings.TrimSpace(getAttr(node, "value")) == "" && !hasAccessibleName(node) {
+			audit.add(node, AccessibilityButtonName, SeverityCritical, "button has no text")
+		}
+	default:
+		if hasAccessibleName(node) || hasAncestor(node, "label") {
+			return
+		}
+		audit.findings = append(audit.findings, pendingFinding{
+			finding: newFinding(node, AccessibilityLabel, SeverityCritical,
+				"form field has no label"),
+			labelID: getAttr(node, "id"),
+		})
+	}
+}
+
+func (audit *accessibilityAudit) add(node *html.Node, rule, severity, message string) {
+	audit.findings = append(audit.findings, pendingFinding{
+		finding: newFinding(node, rule, severity, message),
+	})
+}
+
+// This is to get the findings in page order once the whole page has been visited.
+func (audit *accessibilityAudit) result() []models.AccessibilityFinding {
+	findings := []models.AccessibilityFinding{}
+	for _, pending := range audit.findings {
+		if pending.labelID != "" && audit.labelFor[pending.labelID] {
+			continue
+		}
+		findings = append(findings, pending.finding)
+	}
+	return findings
+}
+
+func newFinding(node *html.Node, rule, severity, message string) models.AccessibilityFinding {
+	return models.AccessibilityFinding{
+		Rule:     rule,
+		Severity: severity,
+		Path:     elementPath(node),
+		Message:  message,
+	}
+}
+
+// This is to check if the element has a name read out by assistive technologies, either
+// from its content or its ARIA attributes or title.
+func hasAccessibleName(node *html.Node) bool {
+	return headingText(node) != "" || strings.TrimSpace(getAttr(node, "aria-labelledby")) != "" ||
+		strings.TrimSpace(getAttr(node, "title")) != ""
+}
+
+// This is to check if the element is explicitly left out for assistive technologies.
+func isHiddenFromAssistiveTech(node *html.Node) bool {
+	role := strings.TrimSpace(getAttr(node, "role"))
+	return getAttr(node, "aria-hidden") == "true" || role == "presentation" || role == "none"
+}
+
+func hasAncestor(node *html.Node, tag string) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if parent.Type == html.ElementNode && parent.Data == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// This is to build a CSS selector like path of the element, such as
+// "html > body > div#main > p:nth-of-type(2) > img". Elements are told apart from their
+// siblings of the same tag by their position.
+func elementPath(node *html.Node) string {
+	var parts []string
+	for ; node != nil && node.Type == html.ElementNode; node = node.Parent {
+		part := node.Data
+		if id := getAttr(node, "id"); id != "" {
+			part += "#" + id
+		} else if position, count := siblingPosition(node); count > 1 {
+			part += fmt.Sprintf(":nth-of-type(%d)", position)
+		}
+		parts = append(parts, part)
+	}
+
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, " > ")
+}
+
+// This is to find the 1-based position of the element among its siblings of the same tag,
+// along with the number of those siblings.
+func siblingPosition(node *html.Node) (int, int) {
+	if node.Parent == nil {
+		return 1, 1
+	}
+	position, count := 0, 0
+	for sibling := node.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type == html.ElementNode && sibling.Data == node.Data {
+			count++
+			if sibling == node {
+				position = count
+			}
+		}
+	}
+	return position, count
+}
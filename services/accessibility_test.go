@@ -0,0 +1,95 @@
+package services
+
+import (
+	"scraper/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHTML_Accessibility(test_type *testing.T) {
+	tests := []struct {
+		name             string
+		htmlContent      string
+		expectedFindings []models.AccessibilityFinding
+	}{
+		{
+			name: "Accessible Page",
+			htmlContent: `
+				<html lang="en"><body>
+					<img src="/logo.png" alt="Logo">
+					<img src="/divider.png" alt="">
+					<a href="/home">Home</a>
+					<a href="/profile"><img src="/avatar.png" alt="Profile"></a>
+					<a href="/close" aria-label="Close"></a>
+					<label for="email">Email</label>
+					<input id="email" type="email">
+					<label>Name <input name="name"></label>
+					<input type="text" name="q" aria-label="Search">
+					<input type="hidden" name="token">
+					<input type="submit">
+					<button>Send</button>
+				</body></html>
+			`,
+			expectedFindings: []models.AccessibilityFinding{},
+		},
+		{
+			name: "Inaccessible Page",
+			htmlContent: `
+				<html><body>
+					<div id="main">
+						<img src="/hero.png">
+						<img src="/spacer.png" role="presentation">
+						<a href="/next"></a>
+						<p><input type="text" name="city"></p>
+						<p><input id="zip" type="text"></p>
+						<button><i class="icon"></i></button>
+						<input type="image" src="/go.png">
+					</div>
+					<span id="main"></span>
+				</body></html>
+			`,
+			expectedFindings: []models.AccessibilityFinding{
+				{Rule: AccessibilityHTMLLang, Severity: SeveritySerious, Path: "html",
+					Message: "html element has no lang"},
+				{Rule: AccessibilityImageAlt, Severity: SeverityCritical,
+					Path: "html > body > div#main > img:nth-of-type(1)", Message: "image has no alt text"},
+				{Rule: AccessibilityLinkName, Severity: SeveritySerious,
+					Path: "html > body > div#main > a", Message: "link has no discernible text"},
+				{Rule: AccessibilityLabel, Severity: SeverityCritical,
+					Path:    "html > body > div#main > p:nth-of-type(1) > input",
+					Message: "form field has no label"},
+				{Rule: AccessibilityLabel, Severity: SeverityCritical,
+					Path:    "html > body > div#main > p:nth-of-type(2) > input#zip",
+					Message: "form field has no label"},
+				{Rule: AccessibilityButtonName, Severity: SeverityCritical,
+					Path: "html > body > div#main > button", Message: "button has no text"},
+				{Rule: AccessibilityImageAlt, Severity: SeverityCritical,
+					Path: "html > body > div#main > input", Message: "image button has no alt text"},
+				{Rule: AccessibilityDuplicateID, Severity: SeverityMinor,
+					Path: "html > body > span#main", Message: `id "main" is used by more than one element`},
+			},
+		},
+		{
+			name: "Label After Field",
+			htmlContent: `
+				<html lang="en"><body>
+					<input id="phone" type="tel">
+					<label for="phone">Phone</label>
+				</body></html>
+			`,
+			expectedFindings: []models.AccessibilityFinding{},
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			result, err := ParseHTML(strings.NewReader(test_data.htmlContent), "http://example.com",
+				ParseOptions{})
+
+			assert.NoError(test_type, err)
+			assert.Equal(test_type, test_data.expectedFindings, result.Accessibility)
+		})
+	}
+}
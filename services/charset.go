@@ -0,0 +1,27 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"io"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/transform"
+)
+
+// Number of bytes sniffed for a byte order mark or <meta charset>, the same as browsers do.
+const charsetSniffLength = 1024
+
+// This is to detect the encoding of the body and get a reader decoding it to UTF-8.
+// Encoding is decided by the byte order mark, then the charset of the Content-Type, then the
+// <meta charset> of the page, and is guessed from the content as the last resort.
+func decodeBody(body io.Reader, contentType string) (io.Reader, string, error) {
+	reader := bufio.NewReaderSize(body, charsetSniffLength)
+	sniffed, err := reader.Peek(charsetSniffLength)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, "", err
+	}
+
+	encoding, name, _ := charset.DetermineEncoding(sniffed, contentType)
+	return transform.NewReader(reader, encoding.NewDecoder()), name, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePage_Charset(test_type *testing.T) {
+	tests := []struct {
+		name             string
+		contentType      string
+		body             string
+		expectedEncoding string
+		expectedTitle    string
+	}{
+		{
+			name:             "Content Type Charset",
+			contentType:      "text/html; charset=windows-1251",
+			body:             "<title>\xcf\xf0\xe8\xe2\xe5\xf2</title>",
+			expectedEncoding: "windows-1251",
+			expectedTitle:    "Привет",
+		},
+		{
+			name:             "Meta Charset",
+			contentType:      "text/html",
+			body:             `<meta charset="Shift_JIS"><title>` + "\x93\xfa\x96\x7b</title>",
+			expectedEncoding: "shift_jis",
+			expectedTitle:    "日本",
+		},
+		{
+			name:        "Meta Http Equiv Charset",
+			contentType: "text/html",
+			body: `<meta http-equiv="Content-Type" content="text/html; charset=ISO-8859-1">` +
+				"<title>Caf\xe9</title>",
+			expectedEncoding: "windows-1252",
+			expectedTitle:    "Café",
+		},
+		{
+			name:             "Byte Order Mark Wins Over Content Type",
+			contentType:      "text/html; charset=windows-1251",
+			body:             "\xef\xbb\xbf<title>Café</title>",
+			expectedEncoding: "utf-8",
+			expectedTitle:    "Café",
+		},
+		{
+			name:             "Undeclared UTF-8",
+			contentType:      "",
+			body:             "<title>Café</title>",
+			expectedEncoding: "utf-8",
+			expectedTitle:    "Café",
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			resp := &http.Response{
+				Header: http.Header{"Content-Type": []string{test_data.contentType}},
+				Body:   io.NopCloser(strings.NewReader(test_data.body)),
+			}
+
+			pageInfo, err := ParsePage(resp, "http://example.com", ParseOptions{})
+
+			assert.NoError(test_type, err)
+			assert.Equal(test_type, test_data.expectedEncoding, pageInfo.Encoding)
+			assert.Equal(test_type, test_data.expectedTitle, pageInfo.Title)
+		})
+	}
+}
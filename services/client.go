@@ -0,0 +1,27 @@
+package services
+
+import (
+	"crypto/tls"
+	"net/http"
+	"scraper/config"
+	"time"
+)
+
+// This is to create the HTTP client used to fetch pages to scrape.
+func NewScrapeClient() *http.Client {
+	return newClient(time.Duration(config.GetOutgoingScrapeRequestTimeout()) * time.Second)
+}
+
+// This is to create the HTTP client used to check the status of scraped URLs.
+func NewURLCheckClient() *http.Client {
+	return newClient(time.Duration(config.GetOutgoingAccessibilityCheckTimeout()) * time.Second)
+}
+
+func newClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // Disable TLS verification
+		},
+		Timeout: timeout,
+	}
+}
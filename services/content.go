@@ -0,0 +1,316 @@
+package services
+
+import (
+	"math"
+	"regexp"
+	"scraper/models"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// Average number of words read in a minute, used to estimate the reading time.
+const readingWordsPerMinute = 200
+
+// Minimum number of stop words found in the text to trust the detected language.
+const minLanguageStopWords = 3
+
+// Number of words of the main text looked at to detect its language.
+const languageSampleWords = 1000
+
+// Minimum length of a paragraph to count towards the score of its container.
+const minParagraphLength = 25
+
+var (
+	positiveContentHint = regexp.MustCompile(
+		`(?i)article|body|content|entry|main|post|text|blog|story`)
+	negativeContentHint = regexp.MustCompile(`(?i)nav|menu|footer|header|sidebar|comment|` +
+		`\bads?\b|advert|banner|promo|sponsor|share|social|related|widget|cookie|popup|modal`)
+)
+
+// Elements which never hold the main content of the page.
+var nonContentElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "form": true, "iframe": true,
+	"svg": true, "button": true, "select": true,
+}
+
+// Elements which are never shown, so their text is not visible.
+var invisibleElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true,
+}
+
+// Elements whose text is kept as a separate paragraph of the main text.
+var paragraphElements = map[string]bool{
+	"p": true, "pre": true, "blockquote": true, "li": true, "td": true, "dd": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "figcaption": true,
+}
+
+// Stop words of languages, which are common enough to tell the language of a text.
+var languageStopWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "was", "on",
+		"are", "this", "you"},
+	"es": {"el", "la", "de", "que", "y", "en", "los", "las", "del", "por", "una", "para", "es",
+		"con", "se"},
+	"fr": {"le", "la", "les", "des", "et", "est", "un", "une", "du", "en", "que", "pour", "dans",
+		"pas", "sur"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "zu", "den", "mit", "von",
+		"sie", "auf", "ich"},
+	"it": {"il", "di", "che", "e", "la", "per", "un", "non", "sono", "del", "della", "una",
+		"con", "gli", "è"},
+	"pt": {"o", "de", "que", "e", "do", "da", "em", "um", "para", "é", "com", "não", "uma", "os",
+		"no"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "zijn", "voor",
+		"met", "die", "ik"},
+}
+
+// Languages in the order they are preferred on equal scores.
+var detectableLanguages = []string{"en", "es", "fr", "de", "it", "pt", "nl"}
+
+// This is to extract the main content of the page the way readability tools do.
+// The visible text of the whole page is counted, while the reading time and language are
+// based on the main content, with the language declared by the page used as a fallback.
+func extractContent(doc *html.Node) *models.PageContent {
+	mainText := extractMainText(findMainContent(doc))
+	mainWords := len(strings.Fields(mainText))
+
+	declaredLanguage := ""
+	traverse(doc, func(node *html.Node) {
+		if declaredLanguage == "" && node.Type == html.ElementNode && node.Data == "html" {
+			declaredLanguage = strings.ToLower(strings.TrimSpace(getAttr(node, "lang")))
+			declaredLanguage, _, _ = strings.Cut(declaredLanguage, "-")
+		}
+	})
+
+	return &models.PageContent{
+		MainText:           mainText,
+		WordCount:          len(strings.Fields(extractVisibleText(doc))),
+		Language:           detectLanguage(mainText, declaredLanguage),
+		ReadingTimeMinutes: int(math.Ceil(float64(mainWords) / readingWordsPerMinute)),
+	}
+}
+
+// This is to find the element holding the main content of the page.
+// Elements marked as the main content are trusted, otherwise containers are scored by the
+// paragraphs they hold, favouring long paragraphs with few links and containers named like
+// content, and penalising ones named like navigation or ads.
+func findMainContent(doc *html.Node) *html.Node {
+	var body, marked *html.Node
+	var articles []*html.Node
+	traverse(doc, func(node *html.Node) {
+		if node.Type != html.ElementNode {
+			return
+		}
+		switch {
+		case node.Data == "body" && body == nil:
+			body = node
+		case (node.Data == "main" || getAttr(node, "role") == "main") && marked == nil:
+			marked = node
+		case node.Data == "article":
+			articles = append(articles, node)
+		}
+	})
+	if marked != nil {
+		return marked
+	}
+	if len(articles) == 1 {
+		return articles[0]
+	}
+
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(node *html.Node, score float64) {
+		if node == nil || node.Type != html.ElementNode {
+			return
+		}
+		if _, found := scores[node]; !found {
+			scores[node] = contentHintWeight(node)
+			candidates = append(candidates, node)
+		}
+		scores[node] += score
+	}
+	traverse(doc, func(node *html.Node) {
+		if node.Type != html.ElementNode || (node.Data != "p" && node.Data != "pre" &&
+			node.Data != "td") || isNonContent(node) {
+			return
+		}
+		text := extractText(node)
+		if len(text) < minParagraphLength {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		addScore(node.Parent, score)
+		if node.Parent != nil {
+			addScore(node.Parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, candidate := range candidates {
+		score := scores[candidate] * (1 - linkDensity(candidate))
+		if best == nil || score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	if best == nil || bestScore <= 0 {
+		return body
+	}
+	return best
+}
+
+// This is to weigh the element by what its class and id tell about it.
+func contentHintWeight(node *html.Node) float64 {
+	hints := getAttr(node, "class") + " " + getAttr(node, "id")
+	weight := 0.0
+	if positiveContentHint.MatchString(hints) {
+		weight += 25
+	}
+	if negativeContentHint.MatchString(hints) {
+		weight -= 25
+	}
+	return weight
+}
+
+// This is to get the share of the text of the element which is in links.
+func linkDensity(node *html.Node) float64 {
+	textLength := len(extractText(node))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	traverse(node, func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "a" {
+			linkLength += len(extractText(node))
+		}
+	})
+	return math.Min(float64(linkLength)/float64(textLength), 1)
+}
+
+// This is to check if the element or any of its ancestors is clearly not the main content.
+func isNonContent(node *html.Node) bool {
+	for ; node != nil; node = node.Parent {
+		if node.Type != html.ElementNode {
+			continue
+		}
+		if nonContentElements[node.Data] || isBoilerplate(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// This is to check if the element is named like navigation, ads or the like.
+func isBoilerplate(node *html.Node) bool {
+	hints := getAttr(node, "class") + " " + getAttr(node, "id")
+	return negativeContentHint.MatchString(hints) && !positiveContentHint.MatchString(hints)
+}
+
+// This is to extract the text of the main content as paragraphs separated by blank lines,
+// leaving out navigation, ads and other boilerplate found within it.
+func extractMainText(content *html.Node) string {
+	if content == nil {
+		return ""
+	}
+
+	var paragraphs []string
+	var inline strings.Builder
+	flush := func() {
+		if text := strings.Join(strings.Fields(inline.String()), " "); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		inline.Reset()
+	}
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			inline.WriteString(node.Data)
+			inline.WriteString(" ")
+			return
+		case html.ElementNode:
+			if node != content && (nonContentElements[node.Data] || isBoilerplate(node)) &&
+				!isArticleHeader(node) {
+				return
+			}
+			if paragraphElements[node.Data] {
+				flush()
+				defer flush()
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(content)
+	flush()
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// This is to check if the element is the header of an article, which usually holds its title
+// rather than the navigation of the site.
+func isArticleHeader(node *html.Node) bool {
+	return node.Data == "header" && !isBoilerplate(node) &&
+		(hasAncestor(node, "article") || hasAncestor(node, "main"))
+}
+
+// This is to extract all text of the page which is shown to the reader.
+func extractVisibleText(doc *html.Node) string {
+	var text strings.Builder
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			text.WriteString(node.Data)
+			text.WriteString(" ")
+			return
+		case html.ElementNode:
+			if invisibleElements[node.Data] || hasAttr(node, "hidden") ||
+				getAttr(node, "aria-hidden") == "true" {
+				return
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return strings.Join(strings.Fields(text.String()), " ")
+}
+
+// This is to detect the language of the text by counting stop words of known languages.
+// The declared language is used if the text is too short to tell.
+func detectLanguage(text, declaredLanguage string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) > languageSampleWords {
+		words = words[:languageSampleWords]
+	}
+
+	counts := make(map[string]int)
+	for _, language := range detectableLanguages {
+		stopWords := make(map[string]bool, len(languageStopWords[language]))
+		for _, word := range languageStopWords[language] {
+			stopWords[word] = true
+		}
+		for _, word := range words {
+			if stopWords[word] {
+				counts[language]++
+			}
+		}
+	}
+
+	detected, bestCount := "", 0
+	for _, language := range detectableLanguages {
+		if counts[language] > bestCount ||
+			(counts[language] == bestCount && language == declaredLanguage) {
+			detected, bestCount = language, counts[language]
+		}
+	}
+	if bestCount < minLanguageStopWords {
+		return declaredLanguage
+	}
+	return detected
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"scraper/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHTML_Content(test_type *testing.T) {
+	article := strings.Repeat("The quick fox jumps over the lazy dog, and it is happy. ", 30)
+	tests := []struct {
+		name            string
+		htmlContent     string
+		options         ParseOptions
+		expectedContent *models.PageContent
+	}{
+		{
+			name:            "Content Not Requested",
+			htmlContent:     `<html><body><p>Some text</p></body></html>`,
+			expectedContent: nil,
+		},
+		{
+			name: "Scored Main Content",
+			htmlContent: `
+				<html lang="de"><body>
+					<div class="nav-menu"><a href="/">Home</a> <a href="/about">About us</a></div>
+					<div class="post-content">
+						<h1>Foxes</h1>
+						<p>` + article + `</p>
+						<div class="share-buttons">Share on social networks</div>
+						<p>Foxes live in forests, fields and even in cities.</p>
+					</div>
+					<div id="sidebar"><p>Advertisement, buy now, limited offer, act fast!</p></div>
+					<script>var tracking = true;</script>
+				</body></html>
+			`,
+			options: ParseOptions{ExtractContent: true},
+			expectedContent: &models.PageContent{
+				MainText: "Foxes\n\n" + strings.TrimSpace(article) +
+					"\n\nFoxes live in forests, fields and even in cities.",
+				WordCount:          384,
+				Language:           "en",
+				ReadingTimeMinutes: 2,
+			},
+		},
+		{
+			name: "Marked Main Content With Declared Language",
+			htmlContent: `
+				<html lang="fr-FR"><body>
+					<header><nav><a href="/">Accueil</a></nav></header>
+					<main>
+						<article>
+							<header><h1>Bonjour</h1></header>
+							<p>Salut tout le monde</p>
+						</article>
+					</main>
+					<footer>Mentions légales</footer>
+				</body></html>
+			`,
+			options: ParseOptions{ExtractContent: true},
+			expectedContent: &models.PageContent{
+				MainText:           "Bonjour\n\nSalut tout le monde",
+				WordCount:          8,
+				Language:           "fr",
+				ReadingTimeMinutes: 1,
+			},
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			result, err := ParseHTML(strings.NewReader(test_data.htmlContent), "http://example.com",
+				test_data.options)
+
+			assert.NoError(test_type, err)
+			assert.Equal(test_type, test_data.expectedContent, result.Content)
+		})
+	}
+}
+
+func TestDetectLanguage(test_type *testing.T) {
+	tests := []struct {
+		name             string
+		text             string
+		declaredLanguage string
+		expectedLanguage string
+	}{
+		{
+			name:             "English",
+			text:             "This is the story of a fox and the dog that it met in the forest.",
+			expectedLanguage: "en",
+		},
+		{
+			name:             "Spanish",
+			text:             "El zorro y el perro se encuentran en el bosque de la montaña para una cena.",
+			expectedLanguage: "es",
+		},
+		{
+			name:             "German Overrides Declared",
+			text:             "Der Fuchs und der Hund sind nicht in dem Wald, die Katze ist auf dem Baum.",
+			declaredLanguage: "en",
+			expectedLanguage: "de",
+		},
+		{
+			name:             "Too Short Falls Back To Declared",
+			text:             "Fox",
+			declaredLanguage: "nl",
+			expectedLanguage: "nl",
+		},
+		{
+			name:             "Unknown",
+			text:             "",
+			expectedLanguage: "",
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			assert.Equal(test_type, test_data.expectedLanguage,
+				detectLanguage(test_data.text, test_data.declaredLanguage))
+		})
+	}
+}
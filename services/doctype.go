@@ -0,0 +1,218 @@
+package services
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Rendering modes browsers pick for a document based on its DOCTYPE.
+const (
+	RenderingQuirks        = "quirks"
+	RenderingLimitedQuirks = "limited-quirks"
+	RenderingStandards     = "standards"
+)
+
+const unknownHTMLVersion = "Unknown Version"
+
+// HTML versions by the public identifier of their DOCTYPE.
+var htmlVersions = map[string]string{
+	"-//w3c//dtd html 4.01//en":              "HTML 4.01 Strict",
+	"-//w3c//dtd html 4.01 transitional//en": "HTML 4.01 Transitional",
+	"-//w3c//dtd html 4.01 frameset//en":     "HTML 4.01 Frameset",
+	"-//w3c//dtd html 4.0//en":               "HTML 4.0 Strict",
+	"-//w3c//dtd html 4.0 transitional//en":  "HTML 4.0 Transitional",
+	"-//w3c//dtd html 4.0 frameset//en":      "HTML 4.0 Frameset",
+	"-//w3c//dtd html 3.2 final//en":         "HTML 3.2",
+	"-//w3c//dtd html 3.2//en":               "HTML 3.2",
+	"-//ietf//dtd html 2.0//en":              "HTML 2.0",
+	"-//ietf//dtd html//en":                  "HTML 2.0",
+	"-//w3c//dtd xhtml 1.0 strict//en":       "XHTML 1.0 Strict",
+	"-//w3c//dtd xhtml 1.0 transitional//en": "XHTML 1.0 Transitional",
+	"-//w3c//dtd xhtml 1.0 frameset//en":     "XHTML 1.0 Frameset",
+	"-//w3c//dtd xhtml 1.1//en":              "XHTML 1.1",
+	"-//w3c//dtd xhtml basic 1.0//en":        "XHTML Basic 1.0",
+	"-//w3c//dtd xhtml basic 1.1//en":        "XHTML Basic 1.1",
+	"-//wapforum//dtd xhtml mobile 1.0//en":  "XHTML Mobile 1.0",
+	"-//wapforum//dtd xhtml mobile 1.1//en":  "XHTML Mobile 1.1",
+	"-//wapforum//dtd xhtml mobile 1.2//en":  "XHTML Mobile 1.2",
+	"-//w3c//dtd xhtml+rdfa 1.0//en":         "XHTML+RDFa 1.0",
+	"-//w3c//dtd xhtml+rdfa 1.1//en":         "XHTML+RDFa 1.1",
+}
+
+// Public identifiers, by exact match or prefix, which switch browsers to quirks mode.
+// Lists are taken from the HTML standard and compared case insensitively.
+var (
+	quirksPublicIDs = []string{
+		"-//w3o//dtd w3 html strict 3.0//en//",
+		"-/w3c/dtd html 4.0 transitional/en",
+		"html",
+	}
+	quirksPublicIDPrefixes = []string{
+		"+//silmaril//dtd html pro v0r11 19970101//",
+		"-//as//dtd html 3.0 aswedit + extensions//",
+		"-//advasoft ltd//dtd html 3.0 aswedit + extensions//",
+		"-//ietf//dtd html 2.0 level 1//",
+		"-//ietf//dtd html 2.0 level 2//",
+		"-//ietf//dtd html 2.0 strict level 1//",
+		"-//ietf//dtd html 2.0 strict level 2//",
+		"-//ietf//dtd html 2.0 strict//",
+		"-//ietf//dtd html 2.0//",
+		"-//ietf//dtd html 2.1e//",
+		"-//ietf//dtd html 3.0//",
+		"-//ietf//dtd html 3.2 final//",
+		"-//ietf//dtd html 3.2//",
+		"-//ietf//dtd html 3//",
+		"-//ietf//dtd html level 0//",
+		"-//ietf//dtd html level 1//",
+		"-//ietf//dtd html level 2//",
+		"-//ietf//dtd html level 3//",
+		"-//ietf//dtd html strict level 0//",
+		"-//ietf//dtd html strict level 1//",
+		"-//ietf//dtd html strict level 2//",
+		"-//ietf//dtd html strict level 3//",
+		"-//ietf//dtd html strict//",
+		"-//ietf//dtd html//",
+		"-//metrius//dtd metrius presentational//",
+		"-//microsoft//dtd internet explorer 2.0 html strict//",
+		"-//microsoft//dtd internet explorer 2.0 html//",
+		"-//microsoft//dtd internet explorer 2.0 tables//",
+		"-//microsoft//dtd internet explorer 3.0 html strict//",
+		"-//microsoft//dtd internet explorer 3.0 html//",
+		"-//microsoft//dtd internet explorer 3.0 tables//",
+		"-//netscape comm. corp.//dtd html//",
+		"-//netscape comm. corp.//dtd strict html//",
+		"-//o'reilly and associates//dtd html 2.0//",
+		"-//o'reilly and associates//dtd html extended 1.0//",
+		"-//o'reilly and associates//dtd html extended relaxed 1.0//",
+		"-//sq//dtd html 2.0 hotmetal + extensions//",
+		"-//softquad software//dtd hotmetal pro 6.0::19990601::extensions to html 4.0//",
+		"-//softquad//dtd hotmetal pro 4.0::19971010::extensions to html 4.0//",
+		"-//spyglass//dtd html 2.0 extended//",
+		"-//sun microsystems corp.//dtd hotjava html//",
+		"-//sun microsystems corp.//dtd hotjava strict html//",
+		"-//w3c//dtd html 3 1995-03-24//",
+		"-//w3c//dtd html 3.2 draft//",
+		"-//w3c//dtd html 3.2 final//",
+		"-//w3c//dtd html 3.2//",
+		"-//w3c//dtd html 3.2s draft//",
+		"-//w3c//dtd html 4.0 frameset//",
+		"-//w3c//dtd html 4.0 transitional//",
+		"-//w3c//dtd html experimental 19960712//",
+		"-//w3c//dtd html experimental 970421//",
+		"-//w3c//dtd w3 html//",
+		"-//w3o//dtd w3 html 3.0//",
+		"-//webtechs//dtd mozilla html 2.0//",
+		"-//webtechs//dtd mozilla html//",
+	}
+	html401FramesetOrTransitional = []string{
+		"-//w3c//dtd html 4.01 frameset//",
+		"-//w3c//dtd html 4.01 transitional//",
+	}
+	xhtml10FramesetOrTransitional = []string{
+		"-//w3c//dtd xhtml 1.0 frameset//",
+		"-//w3c//dtd xhtml 1.0 transitional//",
+	}
+)
+
+// Information declared by the DOCTYPE of a document.
+type doctype struct {
+	found     bool
+	name      string
+	publicID  string
+	systemID  string
+	hasSystem bool
+}
+
+// This is to find out the HTML version of the document from its DOCTYPE, the mode browsers
+// render it in and whether it is an XHTML document.
+func detectDocumentType(doc *html.Node) (version, renderingMode string, isXHTML bool) {
+	declared := extractDoctype(doc)
+	version = htmlVersion(doc, declared)
+	renderingMode = detectRenderingMode(declared)
+	isXHTML = strings.HasPrefix(version, "XHTML") || hasXMLDeclaration(doc)
+	return version, renderingMode, isXHTML
+}
+
+// This is to extract the DOCTYPE of the document. Parser keeps it as a child of the document.
+func extractDoctype(doc *html.Node) doctype {
+	for child := doc.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.DoctypeNode {
+			continue
+		}
+		declared := doctype{found: true, name: strings.ToLower(child.Data)}
+		for _, attr := range child.Attr {
+			switch attr.Key {
+			case "public":
+				declared.publicID = strings.ToLower(strings.TrimSpace(attr.Val))
+			case "system":
+				declared.systemID = strings.ToLower(strings.TrimSpace(attr.Val))
+				declared.hasSystem = true
+			}
+		}
+		return declared
+	}
+	return doctype{}
+}
+
+// This is to name the HTML version declared by the DOCTYPE.
+// Documents without a DOCTYPE fall back to the legacy version attribute of <html>.
+func htmlVersion(doc *html.Node, declared doctype) string {
+	switch {
+	case !declared.found:
+		for child := doc.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && child.Data == "html" && hasAttr(child, "version") {
+				return getAttr(child, "version")
+			}
+		}
+		return unknownHTMLVersion
+	case declared.name != "html":
+		return unknownHTMLVersion
+	case declared.publicID == "" &&
+		(!declared.hasSystem || declared.systemID == "about:legacy-compat"):
+		return "HTML 5"
+	}
+
+	if version, found := htmlVersions[declared.publicID]; found {
+		return version
+	}
+	return unknownHTMLVersion
+}
+
+// This is to decide the rendering mode the same way browsers do, as described by the HTML
+// standard.
+func detectRenderingMode(declared doctype) string {
+	switch {
+	case !declared.found, declared.name != "html",
+		slices.Contains(quirksPublicIDs, declared.publicID),
+		declared.systemID == "http://www.ibm.com/data/dtd/v11/ibmxhtml1-transitional.dtd",
+		hasAnyPrefix(declared.publicID, quirksPublicIDPrefixes),
+		!declared.hasSystem && hasAnyPrefix(declared.publicID, html401FramesetOrTransitional):
+		return RenderingQuirks
+	case hasAnyPrefix(declared.publicID, xhtml10FramesetOrTransitional),
+		declared.hasSystem && hasAnyPrefix(declared.publicID, html401FramesetOrTransitional):
+		return RenderingLimitedQuirks
+	}
+	return RenderingStandards
+}
+
+// This is to check if the document starts with an XML declaration, which the HTML parser
+// keeps as a comment.
+func hasXMLDeclaration(doc *html.Node) bool {
+	for child := doc.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.CommentNode && strings.HasPrefix(child.Data, "?xml") {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
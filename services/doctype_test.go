@@ -0,0 +1,107 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestDetectDocumentType(test_type *testing.T) {
+	tests := []struct {
+		name          string
+		htmlContent   string
+		expectedType  string
+		expectedMode  string
+		expectedXHTML bool
+	}{
+		{
+			name:         "HTML 5",
+			htmlContent:  `<!DOCTYPE html><html><body></body></html>`,
+			expectedType: "HTML 5",
+			expectedMode: RenderingStandards,
+		},
+		{
+			name:         "HTML 5 Legacy Compat",
+			htmlContent:  `<!DOCTYPE html SYSTEM "about:legacy-compat"><html></html>`,
+			expectedType: "HTML 5",
+			expectedMode: RenderingStandards,
+		},
+		{
+			name: "HTML 4.01 Strict",
+			htmlContent: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN"
+				"http://www.w3.org/TR/html4/strict.dtd"><html></html>`,
+			expectedType: "HTML 4.01 Strict",
+			expectedMode: RenderingStandards,
+		},
+		{
+			name: "HTML 4.01 Transitional",
+			htmlContent: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN"
+				"http://www.w3.org/TR/html4/loose.dtd"><html></html>`,
+			expectedType: "HTML 4.01 Transitional",
+			expectedMode: RenderingLimitedQuirks,
+		},
+		{
+			name: "HTML 4.01 Transitional Without System Identifier",
+			htmlContent: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN">
+				<html></html>`,
+			expectedType: "HTML 4.01 Transitional",
+			expectedMode: RenderingQuirks,
+		},
+		{
+			name:         "HTML 3.2",
+			htmlContent:  `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN"><html></html>`,
+			expectedType: "HTML 3.2",
+			expectedMode: RenderingQuirks,
+		},
+		{
+			name: "XHTML 1.0 Strict",
+			htmlContent: `<?xml version="1.0" encoding="UTF-8"?>
+				<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN"
+				"http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd">
+				<html xmlns="http://www.w3.org/1999/xhtml"></html>`,
+			expectedType:  "XHTML 1.0 Strict",
+			expectedMode:  RenderingStandards,
+			expectedXHTML: true,
+		},
+		{
+			name: "XHTML 1.0 Transitional",
+			htmlContent: `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN"
+				"http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd"><html></html>`,
+			expectedType:  "XHTML 1.0 Transitional",
+			expectedMode:  RenderingLimitedQuirks,
+			expectedXHTML: true,
+		},
+		{
+			name:         "Missing DOCTYPE",
+			htmlContent:  `<html><body></body></html>`,
+			expectedType: unknownHTMLVersion,
+			expectedMode: RenderingQuirks,
+		},
+		{
+			name:         "Missing DOCTYPE With Version Attribute",
+			htmlContent:  `<html version="-//W3C//DTD HTML 3.2 Final//EN"></html>`,
+			expectedType: "-//W3C//DTD HTML 3.2 Final//EN",
+			expectedMode: RenderingQuirks,
+		},
+		{
+			name:         "Unknown DOCTYPE",
+			htmlContent:  `<!DOCTYPE svg><html></html>`,
+			expectedType: unknownHTMLVersion,
+			expectedMode: RenderingQuirks,
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			doc, err := html.Parse(strings.NewReader(test_data.htmlContent))
+			assert.NoError(test_type, err)
+
+			version, renderingMode, isXHTML := detectDocumentType(doc)
+			assert.Equal(test_type, test_data.expectedType, version)
+			assert.Equal(test_type, test_data.expectedMode, renderingMode)
+			assert.Equal(test_type, test_data.expectedXHTML, isXHTML)
+		})
+	}
+}
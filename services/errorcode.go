@@ -0,0 +1,69 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"scraper/models"
+	"syscall"
+)
+
+// This is to make sure the URL can be requested before sending anything.
+func validateURL(rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	switch {
+	case err != nil:
+		return fmt.Errorf("%w: %v", errInvalidURL, err)
+	case parsedURL.Scheme != "http" && parsedURL.Scheme != "https":
+		return fmt.Errorf("%w: unsupported scheme %q", errInvalidURL, parsedURL.Scheme)
+	case parsedURL.Hostname() == "":
+		return fmt.Errorf("%w: missing host in %q", errInvalidURL, rawURL)
+	}
+	return nil
+}
+
+// This is to map a failed request to a stable error code.
+func classifyError(err error) models.ErrorCode {
+	var dnsErr *net.DNSError
+	var recordErr tls.RecordHeaderError
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCertErr x509.CertificateInvalidError
+	var alertErr tls.AlertError
+
+	switch {
+	case errors.Is(err, errInvalidURL), errors.Is(err, errMissingRedirectTo):
+		return models.ErrorInvalidURL
+	case errors.Is(err, errTooManyRedirects), errors.Is(err, errRedirectLoop):
+		return models.ErrorTooManyRedirects
+	case isTimeout(err):
+		return models.ErrorTimeout
+	case errors.As(err, &dnsErr):
+		return models.ErrorDNSFailure
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return models.ErrorConnectionRefused
+	case errors.As(err, &recordErr), errors.As(err, &certErr), errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidCertErr), errors.As(err, &alertErr):
+		return models.ErrorTLS
+	}
+	return models.ErrorNetwork
+}
+
+// This is to map the status of a response to a stable error code.
+// Successful and redirect statuses have no error code.
+func classifyStatus(statusCode int) models.ErrorCode {
+	switch {
+	case statusCode == http.StatusUnavailableForLegalReasons:
+		return models.ErrorBlockedByPolicy
+	case statusCode >= 400 && statusCode <= 499:
+		return models.ErrorHTTP4xx
+	case statusCode >= 500 && statusCode <= 599:
+		return models.ErrorHTTP5xx
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"scraper/models"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(test_type *testing.T) {
+	// Wraps the error the same way the HTTP client does.
+	clientError := func(err error) error {
+		return &url.Error{Op: "Head", URL: "http://example.com", Err: err}
+	}
+
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode models.ErrorCode
+	}{
+		{
+			name:         "DNS Failure",
+			err:          clientError(&net.DNSError{Err: "no such host", Name: "example.com"}),
+			expectedCode: models.ErrorDNSFailure,
+		},
+		{
+			name:         "DNS Timeout",
+			err:          clientError(&net.DNSError{Err: "i/o timeout", IsTimeout: true}),
+			expectedCode: models.ErrorTimeout,
+		},
+		{
+			name: "Connection Refused",
+			err: clientError(&net.OpError{Op: "dial", Net: "tcp",
+				Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}),
+			expectedCode: models.ErrorConnectionRefused,
+		},
+		{
+			name: "Unknown Certificate Authority",
+			err: clientError(&tls.CertificateVerificationError{
+				Err: x509.UnknownAuthorityError{}}),
+			expectedCode: models.ErrorTLS,
+		},
+		{
+			name:         "Hostname Mismatch",
+			err:          clientError(x509.HostnameError{Host: "example.com"}),
+			expectedCode: models.ErrorTLS,
+		},
+		{
+			name:         "Expired Certificate",
+			err:          clientError(x509.CertificateInvalidError{Reason: x509.Expired}),
+			expectedCode: models.ErrorTLS,
+		},
+		{
+			name: "Malformed Record",
+			err: clientError(tls.RecordHeaderError{
+				Msg: "first record does not look like a TLS handshake"}),
+			expectedCode: models.ErrorTLS,
+		},
+		{
+			name: "Handshake Failure",
+			err: clientError(&net.OpError{Op: "remote error",
+				Err: tls.AlertError(40)}),
+			expectedCode: models.ErrorTLS,
+		},
+		{
+			name:         "Error Mentioning TLS",
+			err:          clientError(errors.New("tls: something unexpected")),
+			expectedCode: models.ErrorNetwork,
+		},
+		{
+			name:         "Too Many Redirects",
+			err:          fmt.Errorf("%w: stopped after 10 redirects", errTooManyRedirects),
+			expectedCode: models.ErrorTooManyRedirects,
+		},
+		{
+			name:         "Redirect Loop",
+			err:          fmt.Errorf("%w: http://example.com", errRedirectLoop),
+			expectedCode: models.ErrorTooManyRedirects,
+		},
+		{
+			name:         "Invalid URL",
+			err:          validateURL("ftp://example.com/file"),
+			expectedCode: models.ErrorInvalidURL,
+		},
+		{
+			name:         "Connection Reset",
+			err:          clientError(&net.OpError{Op: "read", Err: syscall.ECONNRESET}),
+			expectedCode: models.ErrorNetwork,
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			assert.Equal(test_type, test_data.expectedCode, classifyError(test_data.err))
+		})
+	}
+}
+
+func TestClassifyStatus(test_type *testing.T) {
+	assert.Equal(test_type, models.ErrorCode(""), classifyStatus(http.StatusOK))
+	assert.Equal(test_type, models.ErrorCode(""), classifyStatus(http.StatusPartialContent))
+	assert.Equal(test_type, models.ErrorHTTP4xx, classifyStatus(http.StatusNotFound))
+	assert.Equal(test_type, models.ErrorHTTP5xx, classifyStatus(http.StatusBadGateway))
+	assert.Equal(test_type, models.ErrorBlockedByPolicy,
+		classifyStatus(http.StatusUnavailableForLegalReasons))
+}
+
+func TestValidateURL(test_type *testing.T) {
+	assert.NoError(test_type, validateURL("https://example.com/path"))
+	assert.ErrorIs(test_type, validateURL("mailto:someone@example.com"), errInvalidURL)
+	assert.ErrorIs(test_type, validateURL("http:///path"), errInvalidURL)
+	assert.ErrorIs(test_type, validateURL("http://exa mple.com"), errInvalidURL)
+}
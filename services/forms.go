@@ -0,0 +1,305 @@
+package services
+
+import (
+	"math"
+	"regexp"
+	"scraper/models"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Classes a form is classified into.
+const (
+	FormLogin         = "login"
+	FormSignup        = "signup"
+	FormSearch        = "search"
+	FormPasswordReset = "password-reset"
+	FormPayment       = "payment"
+	FormNewsletter    = "newsletter"
+	FormOther         = "other"
+)
+
+// Minimum confidence for a form to be classified as anything else than other.
+const minFormConfidence = 0.3
+
+// Patterns of names and hints of fields telling what the field is for.
+var (
+	csrfField     = regexp.MustCompile(`(?i)csrf|xsrf|authenticity_token|^_token$|verificationtoken`)
+	usernameField = regexp.MustCompile(`(?i)user|login|email|account`)
+	emailField    = regexp.MustCompile(`(?i)e-?mail`)
+	searchField   = regexp.MustCompile(`(?i)^(q|s|query|search|keywords?)$`)
+	paymentField  = regexp.MustCompile(`(?i)card|cc-?(number|num|exp|csc|cvc)|cvv|cvc|expiry|iban`)
+)
+
+// Patterns of words in attributes of forms and texts of their buttons.
+var (
+	loginKeywords      = regexp.MustCompile(`(?i)log ?in|sign ?in|session`)
+	signupKeywords     = regexp.MustCompile(`(?i)sign ?up|register|create (an )?account|join`)
+	resetKeywords      = regexp.MustCompile(`(?i)reset|forgot|recover|change.?password`)
+	searchKeywords     = regexp.MustCompile(`(?i)search`)
+	paymentKeywords    = regexp.MustCompile(`(?i)pay|checkout|billing|purchase`)
+	newsletterKeywords = regexp.MustCompile(`(?i)newsletter|subscribe|mailing list`)
+)
+
+// A form being collected along with the signals used to classify it.
+type formCollector struct {
+	form     models.FormInfo
+	keywords []string
+	signals  formSignals
+}
+
+type formSignals struct {
+	passwords        int
+	currentPasswords int
+	newPasswords     int
+	usernames        int
+	emails           int
+	searches         int
+	paymentFields    int
+	otherFields      int
+}
+
+// This is to build the inventory of forms on the page.
+// Fields are assigned to forms the way browsers do, so fields placed outside of their <form>
+// and linked with the form attribute are included. Fields which do not belong to any form are
+// collected into a formless entry, as pages often build login forms out of bare inputs.
+func extractForms(doc *html.Node, linkBaseURL string) []models.FormInfo {
+	var collectors []*formCollector
+	formsByID := make(map[string]*formCollector)
+	formsByNode := make(map[*html.Node]*formCollector)
+	traverse(doc, func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "form" {
+			collector := newFormCollector(node, linkBaseURL)
+			collectors = append(collectors, collector)
+			formsByNode[node] = collector
+			if id := getAttr(node, "id"); id != "" && formsByID[id] == nil {
+				formsByID[id] = collector
+			}
+		}
+	})
+
+	var formless *formCollector
+	traverse(doc, func(node *html.Node) {
+		if node.Type != html.ElementNode || !isFormControl(node) {
+			return
+		}
+		collector := owningForm(node, formsByID, formsByNode)
+		if collector == nil {
+			if formless == nil {
+				formless = &formCollector{form: models.FormInfo{
+					Method:   "get",
+					Formless: true,
+					Fields:   []models.FormField{},
+				}}
+			}
+			collector = formless
+		}
+		collector.addControl(node)
+	})
+	if formless != nil && len(formless.form.Fields) > 0 {
+		collectors = append(collectors, formless)
+	}
+
+	forms := []models.FormInfo{}
+	for _, collector := range collectors {
+		collector.form.Classification, collector.form.Confidence = collector.classify()
+		forms = append(forms, collector.form)
+	}
+	return forms
+}
+
+// This is to check if any of the forms is a login form.
+func containsLoginForm(forms []models.FormInfo) bool {
+	for _, form := range forms {
+		if form.Classification == FormLogin {
+			return true
+		}
+	}
+	return false
+}
+
+func newFormCollector(node *html.Node, linkBaseURL string) *formCollector {
+	method := strings.ToLower(strings.TrimSpace(getAttr(node, "method")))
+	if method != "post" && method != "dialog" {
+		method = "get"
+	}
+
+	// Forms without an action are submitted to the page itself.
+	action := linkBaseURL
+	if rawAction := strings.TrimSpace(getAttr(node, "action")); rawAction != "" &&
+		isValidReference(rawAction) {
+		action = resolveURL(linkBaseURL, rawAction)
+	}
+
+	return &formCollector{
+		form: models.FormInfo{
+			Action:          action,
+			Method:          method,
+			Fields:          []models.FormField{},
+			SubmitsOverHTTP: strings.HasPrefix(action, "http://"),
+		},
+		keywords: []string{getAttr(node, "id"), getAttr(node, "name"), getAttr(node, "class"),
+			getAttr(node, "action"), getAttr(node, "aria-label"), getAttr(node, "role")},
+	}
+}
+
+// This is to check if the element is a control submitted with a form or a button of one.
+func isFormControl(node *html.Node) bool {
+	switch node.Data {
+	case "input", "select", "textarea", "button":
+		return true
+	}
+	return false
+}
+
+// This is to find the form a control belongs to. The form attribute takes precedence over
+// the enclosing form.
+func owningForm(node *html.Node, formsByID map[string]*formCollector,
+	formsByNode map[*html.Node]*formCollector) *formCollector {
+	if hasAttr(node, "form") {
+		return formsByID[getAttr(node, "form")]
+	}
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if collector, found := formsByNode[parent]; found {
+			return collector
+		}
+	}
+	return nil
+}
+
+// This is to add a control to the form, recording fields and the texts of buttons.
+func (collector *formCollector) addControl(node *html.Node) {
+	fieldType := node.Data
+	if node.Data == "input" {
+		fieldType = strings.ToLower(strings.TrimSpace(getAttr(node, "type")))
+		if fieldType == "" {
+			fieldType = "text"
+		}
+	}
+
+	switch fieldType {
+	case "button", "submit", "reset", "image":
+		collector.keywords = append(collector.keywords, extractText(node),
+			getAttr(node, "value"), getAttr(node, "name"), getAttr(node, "aria-label"))
+		return
+	}
+
+	field := models.FormField{
+		Name:         getAttr(node, "name"),
+		Type:         fieldType,
+		Autocomplete: strings.ToLower(strings.TrimSpace(getAttr(node, "autocomplete"))),
+	}
+	collector.form.Fields = append(collector.form.Fields, field)
+	if fieldType == "hidden" {
+		if csrfField.MatchString(field.Name) {
+			collector.form.HasCSRFToken = true
+		}
+		return
+	}
+	collector.signals.add(field, getAttr(node, "id")+" "+getAttr(node, "placeholder"))
+}
+
+// This is to count what the field tells about the purpose of the form.
+func (signals *formSignals) add(field models.FormField, hints string) {
+	autocomplete := strings.Fields(field.Autocomplete)
+	hasAutocomplete := func(token string) bool { return slices.Contains(autocomplete, token) }
+	identity := field.Name + " " + hints
+
+	switch {
+	case field.Type == "password":
+		signals.passwords++
+		if hasAutocomplete("current-password") {
+			signals.currentPasswords++
+		}
+		if hasAutocomplete("new-password") {
+			signals.newPasswords++
+		}
+	case hasAutocomplete("cc-number") || hasAutocomplete("cc-exp") || hasAutocomplete("cc-csc") ||
+		paymentField.MatchString(identity):
+		signals.paymentFields++
+	case field.Type == "search" || searchField.MatchString(field.Name):
+		signals.searches++
+	case field.Type == "email" || hasAutocomplete("email") || emailField.MatchString(identity):
+		signals.emails++
+		signals.usernames++
+	case hasAutocomplete("username") || usernameField.MatchString(identity):
+		signals.usernames++
+	case field.Type == "checkbox" || field.Type == "radio":
+		// Remember me, terms and similar options do not change the purpose of a form.
+	default:
+		signals.otherFields++
+	}
+}
+
+// This is to classify the form by scoring each class on the signals of its fields and the
+// keywords of the form and its buttons. Returns the best class with its score as confidence.
+func (collector *formCollector) classify() (string, float64) {
+	signals := collector.signals
+	keywords := strings.Join(collector.keywords, " ")
+	bonus := func(pattern *regexp.Regexp, score float64) float64 {
+		if pattern.MatchString(keywords) {
+			return score
+		}
+		return 0
+	}
+
+	scores := map[string]float64{}
+	if signals.passwords == 1 && signals.newPasswords == 0 {
+		scores[FormLogin] = 0.5
+		if signals.usernames > 0 {
+			scores[FormLogin] += 0.2
+		}
+		if signals.currentPasswords > 0 {
+			scores[FormLogin] += 0.2
+		}
+		scores[FormLogin] += bonus(loginKeywords, 0.2)
+		// Signup forms often ask for nothing more than a login form does.
+		scores[FormLogin] -= bonus(signupKeywords, 0.4)
+		if signals.otherFields > 1 {
+			scores[FormLogin] -= 0.3
+		}
+	}
+	if signals.currentPasswords == 0 && (signals.newPasswords > 0 || signals.passwords > 1 ||
+		(signals.passwords == 1 && (signals.otherFields > 1 || signupKeywords.MatchString(keywords)))) {
+		scores[FormSignup] = 0.5
+		if signals.usernames > 0 {
+			scores[FormSignup] += 0.1
+		}
+		scores[FormSignup] += bonus(signupKeywords, 0.3)
+	}
+	switch {
+	case signals.currentPasswords > 0 && signals.newPasswords > 0:
+		// Changing the password needs the current one and a new one.
+		scores[FormPasswordReset] = 0.8
+	case signals.passwords == 0 && signals.usernames == 1 && signals.otherFields == 0:
+		scores[FormPasswordReset] = bonus(resetKeywords, 0.8)
+	case signals.newPasswords > 0 || signals.passwords > 1:
+		scores[FormPasswordReset] = bonus(resetKeywords, 0.9)
+	}
+	if signals.searches > 0 && signals.passwords == 0 {
+		scores[FormSearch] = 0.6 + bonus(searchKeywords, 0.3)
+	} else if signals.passwords == 0 && signals.otherFields == 1 && signals.usernames == 0 {
+		scores[FormSearch] = bonus(searchKeywords, 0.6)
+	}
+	if signals.paymentFields > 0 {
+		scores[FormPayment] = math.Min(0.4+0.2*float64(signals.paymentFields), 0.9) +
+			bonus(paymentKeywords, 0.1)
+	}
+	if signals.passwords == 0 && signals.emails == 1 && signals.otherFields <= 1 {
+		scores[FormNewsletter] = 0.4 + bonus(newsletterKeywords, 0.5)
+	}
+
+	classification, confidence := FormOther, 0.0
+	for _, class := range []string{FormLogin, FormSignup, FormPasswordReset, FormPayment,
+		FormSearch, FormNewsletter} {
+		if score := scores[class]; score > confidence {
+			classification, confidence = class, score
+		}
+	}
+	if confidence < minFormConfidence {
+		return FormOther, 0
+	}
+	return classification, math.Round(math.Min(confidence, 1)*100) / 100
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"scraper/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHTML_Forms(test_type *testing.T) {
+	tests := []struct {
+		name              string
+		htmlContent       string
+		expectedForms     []models.FormInfo
+		expectedLoginForm bool
+	}{
+		{
+			name: "Login Form",
+			htmlContent: `
+				<form action="/session" method="POST">
+					<input type="hidden" name="csrf_token" value="abc">
+					<input type="email" name="email" autocomplete="username">
+					<input type="password" name="password" autocomplete="current-password">
+					<input type="checkbox" name="remember">
+					<button type="submit">Sign in</button>
+				</form>
+			`,
+			expectedForms: []models.FormInfo{
+				{
+					Action: "http://example.com/session",
+					Method: "post",
+					Fields: []models.FormField{
+						{Name: "csrf_token", Type: "hidden"},
+						{Name: "email", Type: "email", Autocomplete: "username"},
+						{Name: "password", Type: "password", Autocomplete: "current-password"},
+						{Name: "remember", Type: "checkbox"},
+					},
+					HasCSRFToken:    true,
+					SubmitsOverHTTP: true,
+					Classification:  FormLogin,
+					Confidence:      1,
+				},
+			},
+			expectedLoginForm: true,
+		},
+		{
+			name: "Signup Form Is Not A Login Form",
+			htmlContent: `
+				<form action="https://example.com/register" method="post">
+					<input name="username">
+					<input type="password" name="password" autocomplete="new-password">
+					<input type="password" name="confirm" autocomplete="new-password">
+					<input type="submit" value="Create account">
+				</form>
+			`,
+			expectedForms: []models.FormInfo{
+				{
+					Action: "https://example.com/register",
+					Method: "post",
+					Fields: []models.FormField{
+						{Name: "username", Type: "text"},
+						{Name: "password", Type: "password", Autocomplete: "new-password"},
+						{Name: "confirm", Type: "password", Autocomplete: "new-password"},
+					},
+					Classification: FormSignup,
+					Confidence:     0.9,
+				},
+			},
+			expectedLoginForm: false,
+		},
+		{
+			name: "Single Password Signup Form Is Not A Login Form",
+			htmlContent: `
+				<form action="/register" method="post">
+					<input type="email" name="email">
+					<input type="password" name="password">
+					<button type="submit">Sign up</button>
+				</form>
+			`,
+			expectedForms: []models.FormInfo{
+				{
+					Action: "http://example.com/register",
+					Method: "post",
+					Fields: []models.FormField{
+						{Name: "email", Type: "email"},
+						{Name: "password", Type: "password"},
+					},
+					SubmitsOverHTTP: true,
+					Classification:  FormSignup,
+					Confidence:      0.9,
+				},
+			},
+			expectedLoginForm: false,
+		},
+		{
+			name: "Change Password Form Is Not A Login Form",
+			htmlContent: `
+				<form action="/account/password" method="post">
+					<input type="password" name="old" autocomplete="current-password">
+					<input type="password" name="new" autocomplete="new-password">
+				</form>
+			`,
+			expectedForms: []models.FormInfo{
+				{
+					Action: "http://example.com/account/password",
+					Method: "post",
+					Fields: []models.FormField{
+						{Name: "old", Type: "password", Autocomplete: "current-password"},
+						{Name: "new", Type: "password", Autocomplete: "new-password"},
+					},
+					SubmitsOverHTTP: true,
+					Classification:  FormPasswordReset,
+					Confidence:      0.8,
+				},
+			},
+			expectedLoginForm: false,
+		},
+		{
+			name: "Password Field Outside Of Form",
+			htmlContent: `
+				<form id="login" action="https://example.com/login" method="post">
+					<input name="user">
+				</form>
+				<input type="password" name="pass" form="login">
+			`,
+			expectedForms: []models.FormInfo{
+				{
+					Action: "https://example.com/login",
+					Method: "post",
+					Fields: []models.FormField{
+						{Name: "user", Type: "text"},
+						{Name: "pass", Type: "password"},
+					},
+					Classification: FormLogin,
+					Confidence:     0.9,
+				},
+			},
+			expectedLoginForm: true,
+		},
+		{
+			name: "Formless Login Fields",
+			htmlContent: `
+				<div id="login">
+					<input name="login">
+					<input type="password" name="password">
+					<button>Log in</button>
+				</div>
+			`,
+			expectedForms: []models.FormInfo{
+				{
+					Method:   "get",
+					Formless: true,
+					Fields: []models.FormField{
+						{Name: "login", Type: "text"},
+						{Name: "password", Type: "password"},
+					},
+					Classification: FormLogin,
+					Confidence:     0.9,
+				},
+			},
+			expectedLoginForm: true,
+		},
+		{
+			name: "Search, Newsletter, Reset And Payment Forms",
+			htmlContent: `
+				<form role="search" action="/search"><input type="search" name="q"></form>
+				<form action="/newsletter" method="post">
+					<input type="email" name="email"><button>Subscribe</button>
+				</form>
+				<form action="/forgot-password" method="post">
+					<input type="email" name="email"><button>Reset password</button>
+				</form>
+				<form action="/checkout" method="post">
+					<input name="cardnumber" autocomplete="cc-number">
+					<input name="exp" autocomplete="cc-exp">
+					<input name="cvc" autocomplete="cc-csc">
+				</form>
+				<form><textarea name="comment"></textarea></form>
+			`,
+			expectedForms: []models.FormInfo{
+				{
+					Action:          "http://example.com/search",
+					Method:          "get",
+					Fields:          []models.FormField{{Name: "q", Type: "search"}},
+					SubmitsOverHTTP: true,
+					Classification:  FormSearch,
+					Confidence:      0.9,
+				},
+				{
+					Action:          "http://example.com/newsletter",
+					Method:          "post",
+					Fields:          []models.FormField{{Name: "email", Type: "email"}},
+					SubmitsOverHTTP: true,
+					Classification:  FormNewsletter,
+					Confidence:      0.9,
+				},
+				{
+					Action:          "http://example.com/forgot-password",
+					Method:          "post",
+					Fields:          []models.FormField{{Name: "email", Type: "email"}},
+					SubmitsOverHTTP: true,
+					Classification:  FormPasswordReset,
+					Confidence:      0.8,
+				},
+				{
+					Action: "http://example.com/checkout",
+					Method: "post",
+					Fields: []models.FormField{
+						{Name: "cardnumber", Type: "text", Autocomplete: "cc-number"},
+						{Name: "exp", Type: "text", Autocomplete: "cc-exp"},
+						{Name: "cvc", Type: "text", Autocomplete: "cc-csc"},
+					},
+					SubmitsOverHTTP: true,
+					Classification:  FormPayment,
+					Confidence:      1,
+				},
+				{
+					Action:          "http://example.com",
+					Method:          "get",
+					Fields:          []models.FormField{{Name: "comment", Type: "textarea"}},
+					SubmitsOverHTTP: true,
+					Classification:  FormOther,
+				},
+			},
+			expectedLoginForm: false,
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			result, err := ParseHTML(strings.NewReader(test_data.htmlContent), "http://example.com",
+				ParseOptions{})
+
+			assert.NoError(test_type, err)
+			assert.Equal(test_type, test_data.expectedForms, result.Forms)
+			assert.Equal(test_type, test_data.expectedLoginForm, result.ContainsLoginForm)
+		})
+	}
+}
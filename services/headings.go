@@ -0,0 +1,109 @@
+package services
+
+import (
+	"fmt"
+	"scraper/models"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Types of issues found in the heading outline of a page.
+const (
+	HeadingIssueMissingH1    = "missing_h1"
+	HeadingIssueMultipleH1   = "multiple_h1"
+	HeadingIssueSkippedLevel = "skipped_level"
+	HeadingIssueEmpty        = "empty_heading"
+)
+
+// This is to describe a heading element in the order it appears on the page.
+func newHeading(node *html.Node) models.Heading {
+	level, _ := strconv.Atoi(strings.TrimPrefix(node.Data, "h"))
+	return models.Heading{Level: level, Text: headingText(node)}
+}
+
+// This is to get the text a heading is announced with. Headings made of images only are
+// announced by the alternative text of the images.
+func headingText(node *html.Node) string {
+	if text := extractText(node); text != "" {
+		return text
+	}
+	if label := strings.TrimSpace(getAttr(node, "aria-label")); label != "" {
+		return label
+	}
+
+	var alts []string
+	traverse(node, func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "img" {
+			if alt := strings.TrimSpace(getAttr(node, "alt")); alt != "" {
+				alts = append(alts, alt)
+			}
+		}
+	})
+	return strings.Join(alts, " ")
+}
+
+// This is to build the nested heading outline out of headings in page order, and to check
+// the outline for accessibility issues.
+func buildHeadingOutline(headings []models.Heading) ([]models.Heading, []models.HeadingIssue) {
+	issues := []models.HeadingIssue{}
+	h1Count := 0
+	for i, heading := range headings {
+		position := i + 1
+		if heading.Level == 1 {
+			h1Count++
+			if h1Count > 1 {
+				issues = append(issues, models.HeadingIssue{
+					Type:     HeadingIssueMultipleH1,
+					Position: position,
+					Level:    heading.Level,
+					Message:  "page has more than one h1",
+				})
+			}
+		}
+		if i > 0 && heading.Level > headings[i-1].Level+1 {
+			issues = append(issues, models.HeadingIssue{
+				Type:     HeadingIssueSkippedLevel,
+				Position: position,
+				Level:    heading.Level,
+				Message: fmt.Sprintf("heading level skips from h%d to h%d",
+					headings[i-1].Level, heading.Level),
+			})
+		}
+		if heading.Text == "" {
+			issues = append(issues, models.HeadingIssue{
+				Type:     HeadingIssueEmpty,
+				Position: position,
+				Level:    heading.Level,
+				Message:  "heading has no text",
+			})
+		}
+	}
+	if h1Count == 0 {
+		issues = append(issues, models.HeadingIssue{
+			Type:    HeadingIssueMissingH1,
+			Message: "page has no h1",
+		})
+	}
+
+	return nestHeadings(headings), issues
+}
+
+// This is to nest each heading under the closest preceding heading of a higher level.
+func nestHeadings(headings []models.Heading) []models.Heading {
+	outline := []models.Heading{}
+	for i := 0; i < len(headings); {
+		heading := headings[i]
+		next := i + 1
+		for next < len(headings) && headings[next].Level > heading.Level {
+			next++
+		}
+		if next > i+1 {
+			heading.Children = nestHeadings(headings[i+1 : next])
+		}
+		outline = append(outline, heading)
+		i = next
+	}
+	return outline
+}
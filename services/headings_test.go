@@ -0,0 +1,82 @@
+package services
+
+import (
+	"scraper/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHTML_HeadingOutline(test_type *testing.T) {
+	tests := []struct {
+		name            string
+		htmlContent     string
+		expectedOutline []models.Heading
+		expectedIssues  []models.HeadingIssue
+	}{
+		{
+			name: "Valid Outline",
+			htmlContent: `
+				<h1>Guide</h1>
+				<h2>Install <small>v2</small></h2>
+				<h3>Linux</h3>
+				<h3>macOS</h3>
+				<h2><img src="/usage.png" alt="Usage"></h2>
+			`,
+			expectedOutline: []models.Heading{
+				{Level: 1, Text: "Guide", Children: []models.Heading{
+					{Level: 2, Text: "Install v2", Children: []models.Heading{
+						{Level: 3, Text: "Linux"},
+						{Level: 3, Text: "macOS"},
+					}},
+					{Level: 2, Text: "Usage"},
+				}},
+			},
+			expectedIssues: []models.HeadingIssue{},
+		},
+		{
+			name: "Outline With Issues",
+			htmlContent: `
+				<h1>Shop</h1>
+				<h4>Offers</h4>
+				<h1>Cart</h1>
+				<h2> </h2>
+			`,
+			expectedOutline: []models.Heading{
+				{Level: 1, Text: "Shop", Children: []models.Heading{
+					{Level: 4, Text: "Offers"},
+				}},
+				{Level: 1, Text: "Cart", Children: []models.Heading{
+					{Level: 2, Text: ""},
+				}},
+			},
+			expectedIssues: []models.HeadingIssue{
+				{Type: HeadingIssueSkippedLevel, Position: 2, Level: 4,
+					Message: "heading level skips from h1 to h4"},
+				{Type: HeadingIssueMultipleH1, Position: 3, Level: 1,
+					Message: "page has more than one h1"},
+				{Type: HeadingIssueEmpty, Position: 4, Level: 2, Message: "heading has no text"},
+			},
+		},
+		{
+			name:            "No Headings",
+			htmlContent:     `<p>Nothing to see</p>`,
+			expectedOutline: []models.Heading{},
+			expectedIssues: []models.HeadingIssue{
+				{Type: HeadingIssueMissingH1, Message: "page has no h1"},
+			},
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			result, err := ParseHTML(strings.NewReader(test_data.htmlContent), "http://example.com",
+				ParseOptions{})
+
+			assert.NoError(test_type, err)
+			assert.Equal(test_type, test_data.expectedOutline, result.HeadingOutline)
+			assert.Equal(test_type, test_data.expectedIssues, result.HeadingIssues)
+		})
+	}
+}
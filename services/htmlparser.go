@@ -4,63 +4,121 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"scraper/config"
 	"scraper/logger"
 	"scraper/models"
+	"slices"
 	"strings"
 
 	"golang.org/x/net/html"
 	"golang.org/x/net/publicsuffix"
 )
 
+// Optional parts of the page analysis, left out unless asked for.
+type ParseOptions struct {
+	// Extract the main content of the page along with its language and reading time.
+	ExtractContent bool
+}
+
+// This is to fetch the HTML content of the given URL and extract required data.
+func FetchPageInfo(client *http.Client, baseURL string,
+	options ParseOptions) (*models.PageInfo, error) {
+	resp, err := FetchPage(client, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParsePage(resp, baseURL, options)
+}
+
+// This is to parse the fetched page, decoding it to UTF-8 first so that pages in other
+// encodings do not end up garbled. The detected encoding is reported in the page info.
+// Caller is responsible for closing the response body.
+func ParsePage(resp *http.Response, requestedURL string,
+	options ParseOptions) (*models.PageInfo, error) {
+	body, encoding, err := decodeBody(resp.Body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	pageInfo, err := ParseHTML(body, ResponseURL(resp, requestedURL), options)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Encoding = encoding
+	return pageInfo, nil
+}
+
 // This is to fetch the HTML content of the given URL.
-func FetchPageInfo(client *http.Client, baseURL string) (*models.PageInfo, error) {
+// Caller is responsible for closing the response body.
+func FetchPage(client *http.Client, baseURL string) (*http.Response, error) {
 	resp, err := client.Get(baseURL)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return resp, nil
+}
 
-	return ParseHTML(resp.Body, baseURL)
+// This is to get the URL the response was actually served from, after following redirects.
+// Falls back to the requested URL if the response does not carry its request.
+func ResponseURL(resp *http.Response, requestedURL string) string {
+	if resp.Request != nil && resp.Request.URL != nil {
+		return resp.Request.URL.String()
+	}
+	return requestedURL
 }
 
 // This is to parse the HTML content and extract required data.
-func ParseHTML(body io.Reader, baseURL string) (*models.PageInfo, error) {
-	pageInfo := &models.PageInfo{HeadingCounts: make(map[string]int)}
+// Relative links are resolved against the document's <base href>, if any, or else baseURL.
+func ParseHTML(body io.Reader, baseURL string, options ParseOptions) (*models.PageInfo, error) {
+	pageInfo := &models.PageInfo{
+		HeadingCounts:  make(map[string]int),
+		SchemeCounts:   make(map[string]int),
+		ResourceCounts: make(map[string]int),
+	}
+	urlIndexes := make(map[string]int)
+	var headings []models.Heading
 	doc, err := html.Parse(body)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
+	linkBaseURL := extractBaseHref(doc, baseURL)
+	audit := newAccessibilityAudit()
 
 	visitNode := func(node *html.Node) {
+		audit.visit(node)
 		switch node.Type {
 		case html.ElementNode:
+			for _, ref := range extractReferences(node) {
+				collectReference(pageInfo, urlIndexes, baseURL, linkBaseURL, ref, node)
+			}
+
 			switch node.Data {
-			case "html":
-				pageInfo.HTMLVersion = extractHtmlVersion(node)
 			case "h1", "h2", "h3", "h4", "h5", "h6":
 				pageInfo.HeadingCounts[node.Data]++
-			case "a":
-				href := extractHref(node)
-				if href != "" {
-					fullURL := resolveURL(baseURL, href)
-					if isInternal(baseURL, fullURL) {
-						pageInfo.InternalURLsCount++
-					} else {
-						pageInfo.ExternalURLsCount++
-					}
-					pageInfo.URLs = append(pageInfo.URLs, models.URLStatus{URL: fullURL})
-				}
-			case "form":
-				if containsPasswordInput(node) {
-					pageInfo.ContainsLoginForm = true
-				}
+				headings = append(headings, newHeading(node))
+			case "meta":
+				collectMeta(&pageInfo.Metadata, node)
+			case "link":
+				collectLinkTag(&pageInfo.Metadata, node, linkBaseURL)
 			}
 		}
 	}
 
 	traverse(doc, visitNode)
+	pageInfo.HeadingOutline, pageInfo.HeadingIssues = buildHeadingOutline(headings)
+	pageInfo.HTMLVersion, pageInfo.RenderingMode, pageInfo.IsXHTML = detectDocumentType(doc)
+	pageInfo.StructuredData = extractStructuredData(doc, linkBaseURL)
+	pageInfo.Forms = extractForms(doc, linkBaseURL)
+	pageInfo.ContainsLoginForm = containsLoginForm(pageInfo.Forms)
+	pageInfo.Accessibility = audit.result()
+	if options.ExtractContent {
+		pageInfo.Content = extractContent(doc)
+	}
 	pageInfo.Title = extractTitle(doc)
 	return pageInfo, nil
 }
@@ -75,16 +133,128 @@ func traverse(node *html.Node, visit func(*html.Node)) {
 	}
 }
 
-// This is to extract links from the HTML content.
-func extractHref(node *html.Node) string {
+// Schemes of links which are not fetched over HTTP and so can not be resolved the usual way.
+const (
+	schemeFragment = "fragment"
+	schemeInvalid  = "invalid"
+)
+
+// This is to add a resource referenced by the page. Resources are deduplicated by their type
+// and URL, and only ones fetched over HTTP are kept to be checked. Links are counted by their
+// scheme as well, so mailto:, tel: or javascript: links are only counted.
+// References are resolved against linkBaseURL while pageURL decides whether links are internal.
+func collectReference(pageInfo *models.PageInfo, urlIndexes map[string]int,
+	pageURL, linkBaseURL string, ref resourceRef, node *html.Node) {
+	scheme, fullURL := classifyLink(linkBaseURL, ref.href)
+	fetchable := scheme == "http" || scheme == "https"
+	isAnchor := ref.resourceType == models.ResourceAnchor
+
+	key := ref.resourceType + " " + fullURL
+	idx, found := urlIndexes[key]
+	if !found {
+		idx = -1
+		if isAnchor {
+			pageInfo.SchemeCounts[scheme]++
+		}
+		if fetchable {
+			if isAnchor && isInternal(pageURL, fullURL) {
+				pageInfo.InternalURLsCount++
+			} else if isAnchor {
+				pageInfo.ExternalURLsCount++
+			}
+			pageInfo.ResourceCounts[ref.resourceType]++
+			idx = len(pageInfo.URLs)
+			pageInfo.URLs = append(pageInfo.URLs, models.URLStatus{
+				URL:          fullURL,
+				ResourceType: ref.resourceType,
+			})
+		}
+		urlIndexes[key] = idx
+	}
+	if idx < 0 {
+		return
+	}
+
+	urlStatus := &pageInfo.URLs[idx]
+	urlStatus.Occurrences++
+	if !slices.Contains(urlStatus.Origins, ref.origin) {
+		urlStatus.Origins = append(urlStatus.Origins, ref.origin)
+	}
+	if isAnchor {
+		addAnchorText(urlStatus, extractText(node))
+	}
+}
+
+// This is to resolve the link and find out its scheme.
+// Links to a fragment of the same page are classified as such rather than by scheme.
+func classifyLink(baseURL, href string) (string, string) {
+	href = strings.TrimSpace(href)
+	if strings.HasPrefix(href, "#") {
+		return schemeFragment, href
+	}
+	if !isValidReference(href) {
+		return schemeInvalid, href
+	}
+
+	fullURL := normalizeURL(resolveURL(baseURL, href), config.GetURLNormalizeSortQuery())
+	parsedURL, err := url.Parse(fullURL)
+	if err != nil || parsedURL.Scheme == "" {
+		return schemeInvalid, fullURL
+	}
+	return parsedURL.Scheme, fullURL
+}
+
+// This is to find the URL relative links of the document are resolved against.
+// The first <base> element with an href decides it, as browsers do, if it is an HTTP URL.
+func extractBaseHref(doc *html.Node, pageURL string) string {
+	var base *html.Node
+	traverse(doc, func(node *html.Node) {
+		if base == nil && node.Type == html.ElementNode && node.Data == "base" &&
+			hasAttr(node, "href") {
+			base = node
+		}
+	})
+	if base == nil {
+		return pageURL
+	}
+
+	href := strings.TrimSpace(getAttr(base, "href"))
+	if !isValidReference(href) {
+		return pageURL
+	}
+	baseURL := resolveURL(pageURL, href)
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		return pageURL
+	}
+	return baseURL
+}
+
+// This is to get the value of the attribute of the element, if it has one.
+func getAttr(node *html.Node, key string) string {
 	for _, attr := range node.Attr {
-		if attr.Key == "href" {
+		if attr.Key == key {
 			return attr.Val
 		}
 	}
 	return ""
 }
 
+// This is to check if the element has the attribute, even an empty one.
+func hasAttr(node *html.Node, key string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// This is to check if the reference can be parsed, so that it can be resolved to a URL.
+func isValidReference(href string) bool {
+	_, err := url.Parse(href)
+	return err == nil
+}
+
 // This is to build the absolute URL from given baseURL and path.
 func resolveURL(baseURL, href string) string {
 	base, _ := url.Parse(baseURL)
@@ -92,6 +262,53 @@ func resolveURL(baseURL, href string) string {
 	return base.ResolveReference(rel).String()
 }
 
+// This is to bring equivalent URLs to the same form so that they can be deduplicated.
+// Scheme and host are case-folded, default ports and fragments are removed, and query
+// parameters are sorted if asked to.
+func normalizeURL(rawURL string, sortQuery bool) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	parsedURL.Scheme = strings.ToLower(parsedURL.Scheme)
+	parsedURL.Host = strings.ToLower(parsedURL.Host)
+	parsedURL.Fragment = ""
+	parsedURL.RawFragment = ""
+	parsedURL.ForceQuery = false
+
+	port := parsedURL.Port()
+	if (parsedURL.Scheme == "http" && port == "80") || (parsedURL.Scheme == "https" && port == "443") {
+		parsedURL.Host = strings.TrimSuffix(parsedURL.Host, ":"+port)
+	}
+	if parsedURL.Host != "" && parsedURL.Path == "" {
+		parsedURL.Path = "/"
+	}
+	if sortQuery && parsedURL.RawQuery != "" {
+		parsedURL.RawQuery = parsedURL.Query().Encode()
+	}
+	return parsedURL.String()
+}
+
+// This is to record a distinct text the URL is linked with.
+func addAnchorText(urlStatus *models.URLStatus, anchorText string) {
+	if anchorText != "" && !slices.Contains(urlStatus.AnchorTexts, anchorText) {
+		urlStatus.AnchorTexts = append(urlStatus.AnchorTexts, anchorText)
+	}
+}
+
+// This is to extract the visible text of the node with whitespace collapsed.
+func extractText(node *html.Node) string {
+	var text strings.Builder
+	traverse(node, func(node *html.Node) {
+		if node.Type == html.TextNode {
+			text.WriteString(node.Data)
+			text.WriteString(" ")
+		}
+	})
+	return strings.Join(strings.Fields(text.String()), " ")
+}
+
 // This is to compare TLDs of found URLs against the scraped page URL
 // to determine if found URLs are internal links or external link.
 func isInternal(baseUrl, scrappedUrl string) bool {
@@ -104,24 +321,6 @@ func isInternal(baseUrl, scrappedUrl string) bool {
 	return strings.EqualFold(baseUrlTld, scrappedUrlTld)
 }
 
-// This is to check if the scraped HTML content has a password input.
-// Based on this we decided if the page contains a login form.
-func containsPasswordInput(node *html.Node) bool {
-	if node.Type == html.ElementNode && node.Data == "input" {
-		for _, attr := range node.Attr {
-			if attr.Key == "type" && attr.Val == "password" {
-				return true
-			}
-		}
-	}
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		if containsPasswordInput(child) {
-			return true
-		}
-	}
-	return false
-}
-
 // This is to extract the scraped page title from the HTML title tag.
 func extractTitle(node *html.Node) string {
 	if node.Type == html.ElementNode && node.Data == "title" && node.FirstChild != nil {
@@ -135,20 +334,3 @@ func extractTitle(node *html.Node) string {
 	}
 	return ""
 }
-
-// This is to extract the HTML version of the scraped page.
-func extractHtmlVersion(node *html.Node) string {
-	// Check for a "version" attribute
-	for _, attr := range node.Attr {
-		if attr.Key == "version" {
-			return attr.Val
-		}
-	}
-
-	// Check for DOCTYPE version
-	if node.Data == "html" && node.Type == html.ElementNode {
-		return "HTML 5" // Default to HTML5
-	}
-
-	return "Unknown Version"
-}
@@ -12,6 +12,17 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// This is to build the expected status of a URL found on an anchor.
+func anchorURL(url string, occurrences int, anchorTexts ...string) models.URLStatus {
+	return models.URLStatus{
+		URL:          url,
+		ResourceType: models.ResourceAnchor,
+		Origins:      []string{"a[href]"},
+		Occurrences:  occurrences,
+		AnchorTexts:  anchorTexts,
+	}
+}
+
 func TestFetchPageInfo(test_type *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -22,6 +33,8 @@ func TestFetchPageInfo(test_type *testing.T) {
 	tests := []struct {
 		name       string
 		mockURL    string
+		redirectTo string
+		finalURL   string
 		mockBody   string
 		mockStatus int
 		mockError  error
@@ -38,6 +51,20 @@ func TestFetchPageInfo(test_type *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name:       "Redirected HTML Page",
+			mockURL:    "http://old-url",
+			redirectTo: "//new-url/dir/",
+			finalURL:   "http://new-url/dir/",
+			mockBody:   `<html><body><a href="page">Page</a></body></html>`,
+			mockStatus: http.StatusOK,
+			expected: &models.PageInfo{
+				URLs: []models.URLStatus{
+					anchorURL("http://new-url/dir/page", 1, "Page"),
+				},
+			},
+			expectErr: false,
+		},
 		{
 			name:      "HTTP Get Error",
 			mockURL:   "http://invalid-url",
@@ -52,12 +79,19 @@ func TestFetchPageInfo(test_type *testing.T) {
 			if test_data.mockError != nil {
 				httpmock.RegisterResponder("GET", test_data.mockURL,
 					httpmock.NewErrorResponder(test_data.mockError))
+			} else if test_data.redirectTo != "" {
+				redirect := httpmock.NewStringResponse(http.StatusMovedPermanently, "")
+				redirect.Header.Set("Location", test_data.redirectTo)
+				httpmock.RegisterResponder("GET", test_data.mockURL,
+					httpmock.ResponderFromResponse(redirect))
+				httpmock.RegisterResponder("GET", test_data.finalURL,
+					httpmock.NewStringResponder(test_data.mockStatus, test_data.mockBody))
 			} else {
 				httpmock.RegisterResponder("GET", test_data.mockURL,
 					httpmock.NewStringResponder(test_data.mockStatus, test_data.mockBody))
 			}
 
-			pageInfo, err := FetchPageInfo(client, test_data.mockURL)
+			pageInfo, err := FetchPageInfo(client, test_data.mockURL, ParseOptions{})
 
 			if test_data.expectErr {
 				if err == nil {
@@ -70,6 +104,7 @@ func TestFetchPageInfo(test_type *testing.T) {
 				if pageInfo.Title != test_data.expected.Title {
 					test_type.Errorf("Expected title %s, got %s", test_data.expected.Title, pageInfo.Title)
 				}
+				assert.Equal(test_type, test_data.expected.URLs, pageInfo.URLs)
 			}
 		})
 	}
@@ -106,10 +141,99 @@ func TestParseHTML(test_type *testing.T) {
 				HeadingCounts:     map[string]int{"h1": 1},
 				InternalURLsCount: 1,
 				ExternalURLsCount: 1,
+				SchemeCounts:      map[string]int{"http": 2},
 				ContainsLoginForm: true,
 				URLs: []models.URLStatus{
-					{URL: "http://example.com/internal"},
-					{URL: "http://external.com"},
+					anchorURL("http://example.com/internal", 1, "Internal Link"),
+					anchorURL("http://external.com/", 1, "External Link"),
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Repeated Links",
+			htmlContent: `
+				<!DOCTYPE html>
+				<html>
+					<body>
+						<nav><a href="/about">About</a></nav>
+						<a href="HTTP://Example.com:80/about#team">About   us</a>
+						<footer><a href="/about"><span>About</span></a></footer>
+						<a href="https://external.com:443"></a>
+					</body>
+				</html>
+			`,
+			baseURL: "http://example.com",
+			expectedResult: &models.PageInfo{
+				HTMLVersion:       "HTML 5",
+				HeadingCounts:     map[string]int{},
+				InternalURLsCount: 1,
+				ExternalURLsCount: 1,
+				SchemeCounts:      map[string]int{"http": 1, "https": 1},
+				URLs: []models.URLStatus{
+					anchorURL("http://example.com/about", 3, "About", "About us"),
+					anchorURL("https://external.com/", 1),
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Base Href",
+			htmlContent: `
+				<!DOCTYPE html>
+				<html>
+					<head>
+						<base target="_blank">
+						<base href="/docs/v2/">
+						<base href="http://ignored.com/">
+					</head>
+					<body>
+						<a href="intro.html">Intro</a>
+						<a href="/home">Home</a>
+						<a href="https://cdn.example.com/guide.pdf">Guide</a>
+					</body>
+				</html>
+			`,
+			baseURL: "http://example.com/index.html",
+			expectedResult: &models.PageInfo{
+				HTMLVersion:       "HTML 5",
+				HeadingCounts:     map[string]int{},
+				InternalURLsCount: 3,
+				SchemeCounts:      map[string]int{"http": 2, "https": 1},
+				URLs: []models.URLStatus{
+					anchorURL("http://example.com/docs/v2/intro.html", 1, "Intro"),
+					anchorURL("http://example.com/home", 1, "Home"),
+					anchorURL("https://cdn.example.com/guide.pdf", 1, "Guide"),
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Non HTTP Links",
+			htmlContent: `
+				<!DOCTYPE html>
+				<html>
+					<body>
+						<a href="#top">Top</a>
+						<a href="mailto:info@example.com">Mail</a>
+						<a href="tel:+31201234567">Call</a>
+						<a href="javascript:void(0)">Menu</a>
+						<a href=" JavaScript:void(0)">Menu</a>
+						<a href="data:text/plain;base64,SGVsbG8=">Download</a>
+						<a href="http://[::1">Broken</a>
+						<a href="/contact">Contact</a>
+					</body>
+				</html>
+			`,
+			baseURL: "http://example.com",
+			expectedResult: &models.PageInfo{
+				HTMLVersion:       "HTML 5",
+				HeadingCounts:     map[string]int{},
+				InternalURLsCount: 1,
+				SchemeCounts: map[string]int{"fragment": 1, "mailto": 1, "tel": 1, "javascript": 1,
+					"data": 1, "invalid": 1, "http": 1},
+				URLs: []models.URLStatus{
+					anchorURL("http://example.com/contact", 1, "Contact"),
 				},
 			},
 			expectedError: nil,
@@ -120,7 +244,7 @@ func TestParseHTML(test_type *testing.T) {
 		test_type.Run(test_data.name, func(test_type *testing.T) {
 
 			body := bytes.NewReader([]byte(test_data.htmlContent))
-			result, err := ParseHTML(body, test_data.baseURL)
+			result, err := ParseHTML(body, test_data.baseURL, ParseOptions{})
 
 			if test_data.expectedError != nil {
 				assert.Error(test_type, err)
@@ -132,9 +256,38 @@ func TestParseHTML(test_type *testing.T) {
 				assert.Equal(test_type, test_data.expectedResult.HeadingCounts, result.HeadingCounts)
 				assert.Equal(test_type, test_data.expectedResult.InternalURLsCount, result.InternalURLsCount)
 				assert.Equal(test_type, test_data.expectedResult.ExternalURLsCount, result.ExternalURLsCount)
+				assert.Equal(test_type, test_data.expectedResult.SchemeCounts, result.SchemeCounts)
 				assert.Equal(test_type, test_data.expectedResult.ContainsLoginForm, result.ContainsLoginForm)
 				assert.ElementsMatch(test_type, test_data.expectedResult.URLs, result.URLs)
 			}
 		})
 	}
 }
+
+func TestNormalizeURL(test_type *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		sortQuery bool
+		expected  string
+	}{
+		{name: "Case Folding", url: "HTTP://WWW.Example.COM/Path",
+			expected: "http://www.example.com/Path"},
+		{name: "Fragment", url: "http://example.com/page#section", expected: "http://example.com/page"},
+		{name: "Default HTTP Port", url: "http://example.com:80/", expected: "http://example.com/"},
+		{name: "Default HTTPS Port", url: "https://example.com:443", expected: "https://example.com/"},
+		{name: "Custom Port", url: "http://example.com:8080/", expected: "http://example.com:8080/"},
+		{name: "Empty Query", url: "http://example.com/?", expected: "http://example.com/"},
+		{name: "Unsorted Query", url: "http://example.com/?b=2&a=1",
+			expected: "http://example.com/?b=2&a=1"},
+		{name: "Sorted Query", url: "http://example.com/?b=2&a=1", sortQuery: true,
+			expected: "http://example.com/?a=1&b=2"},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			assert.Equal(test_type, test_data.expected,
+				normalizeURL(test_data.url, test_data.sortQuery))
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"net/url"
+	"scraper/config"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hosts without any request for this long are forgotten by the limiter.
+const idleHostTimeout = time.Minute
+
+// This is to limit outgoing URL check requests across all scrape requests in the process.
+// Number of requests in flight is bounded in total and per host, and requests to the same
+// host are rate limited by a token bucket.
+type urlCheckLimiter struct {
+	sync.Mutex
+	slots           chan struct{}
+	hostRate        float64
+	hostBurst       int
+	hostMaxInFlight int
+	hosts           map[string]*hostLimit
+	now             func() time.Time
+}
+
+// Limits of requests to a single host.
+type hostLimit struct {
+	tokens    float64
+	updatedAt time.Time
+	inFlight  chan struct{}
+	users     int
+}
+
+// Limiter shared by all URL checks in the process.
+var limiter = newURLCheckLimiter(config.GetURLCheckWorkerPoolSize(),
+	config.GetURLCheckHostRateLimit(), config.GetURLCheckHostBurst(),
+	config.GetURLCheckHostMaxInFlight())
+
+// This is to create a limiter. Zero or negative host rate disables the rate limit.
+func newURLCheckLimiter(maxInFlight int, hostRate float64, hostBurst,
+	hostMaxInFlight int) *urlCheckLimiter {
+	return &urlCheckLimiter{
+		slots:           make(chan struct{}, max(maxInFlight, 1)),
+		hostRate:        hostRate,
+		hostBurst:       max(hostBurst, 1),
+		hostMaxInFlight: max(hostMaxInFlight, 1),
+		hosts:           make(map[string]*hostLimit),
+		now:             time.Now,
+	}
+}
+
+// This is to wait until a request to the given host is allowed.
+// Returned function has to be called once the request is done.
+func (limiter *urlCheckLimiter) acquire(host string) func() {
+	limit, wait := limiter.reserve(host)
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+
+	limit.inFlight <- struct{}{}
+	limiter.slots <- struct{}{}
+
+	return func() {
+		<-limiter.slots
+		<-limit.inFlight
+
+		limiter.Lock()
+		limit.users--
+		limiter.Unlock()
+	}
+}
+
+// This is to take a token from the host bucket.
+// Returns how long to wait until the taken token becomes available.
+func (limiter *urlCheckLimiter) reserve(host string) (*hostLimit, time.Duration) {
+	limiter.Lock()
+	defer limiter.Unlock()
+
+	now := limiter.now()
+	limit, exists := limiter.hosts[host]
+	if !exists {
+		limiter.forgetIdleHosts(now)
+		limit = &hostLimit{
+			tokens:    float64(limiter.hostBurst),
+			updatedAt: now,
+			inFlight:  make(chan struct{}, limiter.hostMaxInFlight),
+		}
+		limiter.hosts[host] = limit
+	}
+	limit.users++
+
+	if limiter.hostRate <= 0 {
+		return limit, 0
+	}
+
+	// Refill tokens for the time passed since the last reservation.
+	elapsed := now.Sub(limit.updatedAt).Seconds()
+	limit.tokens = min(float64(limiter.hostBurst), limit.tokens+elapsed*limiter.hostRate)
+	limit.updatedAt = now
+
+	// Tokens can go negative, which queues up reservations behind each other.
+	limit.tokens--
+	if limit.tokens >= 0 {
+		return limit, 0
+	}
+	return limit, time.Duration(-limit.tokens / limiter.hostRate * float64(time.Second))
+}
+
+// This is to forget hosts which are not used and have not been used for a while.
+// Caller must hold the limiter lock.
+func (limiter *urlCheckLimiter) forgetIdleHosts(now time.Time) {
+	for host, limit := range limiter.hosts {
+		if limit.users == 0 && now.Sub(limit.updatedAt) > idleHostTimeout {
+			delete(limiter.hosts, host)
+		}
+	}
+}
+
+// This is to get the host of the URL which requests are limited by.
+func urlHost(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsedURL.Hostname())
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This is to check whether acquiring a slot for the host blocks.
+func acquiresWithoutBlocking(limiter *urlCheckLimiter, host string) (bool, func()) {
+	acquired := make(chan func(), 1)
+	go func() { acquired <- limiter.acquire(host) }()
+
+	select {
+	case release := <-acquired:
+		return true, release
+	case <-time.After(50 * time.Millisecond):
+		// Release the pending acquisition once it goes through.
+		return false, func() { (<-acquired)() }
+	}
+}
+
+func TestURLCheckLimiter_HostRate(test_type *testing.T) {
+	limiter := newURLCheckLimiter(10, 10, 2, 10)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	// Burst is allowed right away, then requests are spaced by the rate.
+	_, wait := limiter.reserve("example.com")
+	assert.Equal(test_type, time.Duration(0), wait)
+	_, wait = limiter.reserve("example.com")
+	assert.Equal(test_type, time.Duration(0), wait)
+	_, wait = limiter.reserve("example.com")
+	assert.Equal(test_type, 100*time.Millisecond, wait)
+	_, wait = limiter.reserve("example.com")
+	assert.Equal(test_type, 200*time.Millisecond, wait)
+
+	// Other hosts have their own bucket.
+	_, wait = limiter.reserve("other.com")
+	assert.Equal(test_type, time.Duration(0), wait)
+
+	// Tokens are refilled over time.
+	now = now.Add(time.Second)
+	_, wait = limiter.reserve("example.com")
+	assert.Equal(test_type, time.Duration(0), wait)
+}
+
+func TestURLCheckLimiter_NoHostRate(test_type *testing.T) {
+	limiter := newURLCheckLimiter(10, 0, 1, 10)
+
+	for i := 0; i < 5; i++ {
+		_, wait := limiter.reserve("example.com")
+		assert.Equal(test_type, time.Duration(0), wait, "Disabled rate limit should not wait")
+	}
+}
+
+func TestURLCheckLimiter_HostMaxInFlight(test_type *testing.T) {
+	limiter := newURLCheckLimiter(10, 0, 1, 1)
+
+	release := limiter.acquire("example.com")
+
+	acquired, releaseOther := acquiresWithoutBlocking(limiter, "other.com")
+	assert.True(test_type, acquired, "Other hosts should not be blocked")
+	releaseOther()
+
+	acquired, releaseSecond := acquiresWithoutBlocking(limiter, "example.com")
+	assert.False(test_type, acquired, "Host should be limited to a single request in flight")
+
+	release()
+	releaseSecond()
+}
+
+func TestURLCheckLimiter_MaxInFlight(test_type *testing.T) {
+	limiter := newURLCheckLimiter(1, 0, 1, 10)
+
+	release := limiter.acquire("example.com")
+
+	acquired, releaseOther := acquiresWithoutBlocking(limiter, "other.com")
+	assert.False(test_type, acquired, "Requests should be limited in total across hosts")
+
+	release()
+	releaseOther()
+}
+
+func TestURLCheckLimiter_ForgetIdleHosts(test_type *testing.T) {
+	limiter := newURLCheckLimiter(10, 1, 1, 1)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	limiter.acquire("idle.com")()
+	busyRelease := limiter.acquire("busy.com")
+
+	now = now.Add(2 * idleHostTimeout)
+	limiter.acquire("new.com")()
+
+	assert.NotContains(test_type, limiter.hosts, "idle.com", "Idle host should be forgotten")
+	assert.Contains(test_type, limiter.hosts, "busy.com", "Host in use should be kept")
+	busyRelease()
+}
+
+func TestURLHost(test_type *testing.T) {
+	assert.Equal(test_type, "example.com", urlHost("https://Example.COM:8443/path"))
+	assert.Equal(test_type, "", urlHost("://invalid"))
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"mime"
+	"scraper/models"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// This is to extract SEO related metadata from a <meta> element.
+// When a tag is repeated the first one wins, as search engines mostly do.
+func collectMeta(metadata *models.PageMetadata, node *html.Node) {
+	content := strings.TrimSpace(getAttr(node, "content"))
+	name := strings.ToLower(strings.TrimSpace(getAttr(node, "name")))
+	property := strings.ToLower(strings.TrimSpace(getAttr(node, "property")))
+
+	switch {
+	case hasAttr(node, "charset"):
+		setIfEmpty(&metadata.Charset, strings.TrimSpace(getAttr(node, "charset")))
+	case strings.EqualFold(getAttr(node, "http-equiv"), "content-type"):
+		if _, params, err := mime.ParseMediaType(content); err == nil {
+			setIfEmpty(&metadata.Charset, params["charset"])
+		}
+	case name == "description":
+		setIfEmpty(&metadata.Description, content)
+	case name == "robots":
+		setIfEmpty(&metadata.Robots, content)
+	case name == "viewport":
+		setIfEmpty(&metadata.Viewport, content)
+	case strings.HasPrefix(property, "og:"):
+		metadata.OpenGraph = addTag(metadata.OpenGraph, property, content)
+	case strings.HasPrefix(name, "twitter:"):
+		metadata.TwitterCard = addTag(metadata.TwitterCard, name, content)
+	case strings.HasPrefix(property, "twitter:"):
+		// Twitter also reads its tags from the property attribute used by Open Graph.
+		metadata.TwitterCard = addTag(metadata.TwitterCard, property, content)
+	}
+}
+
+// This is to extract the canonical URL and hreflang alternates from a <link> element.
+// URLs are resolved against the base URL of the document.
+func collectLinkTag(metadata *models.PageMetadata, node *html.Node, linkBaseURL string) {
+	href := strings.TrimSpace(getAttr(node, "href"))
+	if href == "" || !isValidReference(href) {
+		return
+	}
+
+	for _, rel := range strings.Fields(strings.ToLower(getAttr(node, "rel"))) {
+		switch rel {
+		case "canonical":
+			setIfEmpty(&metadata.Canonical, resolveURL(linkBaseURL, href))
+		case "alternate":
+			if hreflang := strings.TrimSpace(getAttr(node, "hreflang")); hreflang != "" {
+				metadata.Alternates = append(metadata.Alternates, models.HreflangAlternate{
+					Hreflang: hreflang,
+					URL:      resolveURL(linkBaseURL, href),
+				})
+			}
+		}
+	}
+}
+
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+func addTag(tags map[string]string, key, value string) map[string]string {
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	if _, found := tags[key]; !found {
+		tags[key] = value
+	}
+	return tags
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"scraper/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHTML_Metadata(test_type *testing.T) {
+	tests := []struct {
+		name             string
+		htmlContent      string
+		expectedMetadata models.PageMetadata
+	}{
+		{
+			name: "Full Metadata",
+			htmlContent: `
+				<!DOCTYPE html>
+				<html>
+					<head>
+						<meta charset="utf-8">
+						<meta name="viewport" content="width=device-width, initial-scale=1">
+						<meta name="Description" content=" Example products ">
+						<meta name="description" content="Ignored duplicate">
+						<meta name="robots" content="noindex, nofollow">
+						<link rel="canonical" href="/products">
+						<link rel="alternate" hreflang="en" href="https://example.com/en/">
+						<link rel="alternate" hreflang="x-default" href="/">
+						<link rel="alternate stylesheet" href="/dark.css">
+						<meta property="og:title" content="Products">
+						<meta property="og:image" content="https://example.com/a.png">
+						<meta property="og:image" content="https://example.com/b.png">
+						<meta name="twitter:card" content="summary_large_image">
+						<meta property="twitter:site" content="@example">
+					</head>
+				</html>
+			`,
+			expectedMetadata: models.PageMetadata{
+				Description: "Example products",
+				Robots:      "noindex, nofollow",
+				Canonical:   "http://example.com/products",
+				Alternates: []models.HreflangAlternate{
+					{Hreflang: "en", URL: "https://example.com/en/"},
+					{Hreflang: "x-default", URL: "http://example.com/"},
+				},
+				OpenGraph: map[string]string{
+					"og:title": "Products",
+					"og:image": "https://example.com/a.png",
+				},
+				TwitterCard: map[string]string{
+					"twitter:card": "summary_large_image",
+					"twitter:site": "@example",
+				},
+				Viewport: "width=device-width, initial-scale=1",
+				Charset:  "utf-8",
+			},
+		},
+		{
+			name: "Legacy Charset Declaration",
+			htmlContent: `
+				<html>
+					<head>
+						<meta http-equiv="Content-Type" content="text/html; charset=Shift_JIS">
+					</head>
+				</html>
+			`,
+			expectedMetadata: models.PageMetadata{Charset: "Shift_JIS"},
+		},
+		{
+			name:             "No Metadata",
+			htmlContent:      `<html><head><title>Empty</title></head></html>`,
+			expectedMetadata: models.PageMetadata{},
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			result, err := ParseHTML(strings.NewReader(test_data.htmlContent), "http://example.com",
+				ParseOptions{})
+
+			assert.NoError(test_type, err)
+			assert.Equal(test_type, test_data.expectedMetadata, result.Metadata)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"scraper/models"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Resource types URLs are checked by when no types are requested.
+var DefaultResourceTypes = []string{models.ResourceAnchor}
+
+// A reference to a resource found on an element of the page.
+type resourceRef struct {
+	resourceType string
+	origin       string
+	href         string
+}
+
+// This is to extract references to other resources from an element, such as links, images,
+// stylesheets, scripts, frames, media and form targets. Origin tells the element and the
+// attribute each reference was found on.
+func extractReferences(node *html.Node) []resourceRef {
+	var refs []resourceRef
+	add := func(resourceType, attr string) {
+		if value := strings.TrimSpace(getAttr(node, attr)); value != "" {
+			refs = append(refs, resourceRef{resourceType, node.Data + "[" + attr + "]", value})
+		}
+	}
+	addSrcset := func(resourceType, attr string) {
+		for _, candidate := range parseSrcset(getAttr(node, attr)) {
+			refs = append(refs, resourceRef{resourceType, node.Data + "[" + attr + "]", candidate})
+		}
+	}
+
+	switch node.Data {
+	case "a", "area":
+		add(models.ResourceAnchor, "href")
+	case "img":
+		add(models.ResourceImage, "src")
+		addSrcset(models.ResourceImage, "srcset")
+	case "source":
+		if node.Parent != nil && node.Parent.Data == "picture" {
+			addSrcset(models.ResourceImage, "srcset")
+		} else {
+			add(models.ResourceMedia, "src")
+		}
+	case "video":
+		add(models.ResourceMedia, "src")
+		add(models.ResourceImage, "poster")
+	case "audio", "track":
+		add(models.ResourceMedia, "src")
+	case "script":
+		add(models.ResourceScript, "src")
+	case "iframe", "frame":
+		add(models.ResourceFrame, "src")
+	case "embed":
+		add(models.ResourceObject, "src")
+	case "object":
+		add(models.ResourceObject, "data")
+	case "link":
+		rels := strings.Fields(strings.ToLower(getAttr(node, "rel")))
+		if slices.Contains(rels, "stylesheet") {
+			add(models.ResourceStylesheet, "href")
+		} else {
+			add(models.ResourceLink, "href")
+		}
+	case "form":
+		add(models.ResourceForm, "action")
+	case "button":
+		add(models.ResourceForm, "formaction")
+	case "input":
+		if strings.EqualFold(getAttr(node, "type"), "image") {
+			add(models.ResourceImage, "src")
+		}
+		add(models.ResourceForm, "formaction")
+	}
+	return refs
+}
+
+// This is to extract the URLs of image candidates listed by a srcset attribute, such as
+// "small.png 1x, large.png 2x".
+func parseSrcset(srcset string) []string {
+	var candidates []string
+	for rest := srcset; ; {
+		rest = strings.TrimLeft(rest, " \t\n\r\f,")
+		if rest == "" {
+			return candidates
+		}
+
+		end := strings.IndexAny(rest, " \t\n\r\f")
+		if end < 0 {
+			end = len(rest)
+		}
+		candidate := rest[:end]
+		rest = rest[end:]
+		if trimmed := strings.TrimRight(candidate, ","); trimmed != candidate {
+			// URL directly followed by a comma has no descriptors.
+			candidates = append(candidates, trimmed)
+			continue
+		}
+		candidates = append(candidates, candidate)
+
+		// Skip descriptors up to the next candidate.
+		if next := strings.IndexByte(rest, ','); next >= 0 {
+			rest = rest[next+1:]
+		} else {
+			rest = ""
+		}
+	}
+}
+
+// This is to select the URLs of the given resource types. All URLs are selected if no types
+// are given. Returned URLs are copies, so checking them does not change the given ones.
+// URLs stored before resource types were collected have no type, and are all anchors.
+func FilterURLsByType(urls []models.URLStatus, resourceTypes []string) []models.URLStatus {
+	filtered := []models.URLStatus{}
+	for _, urlStatus := range urls {
+		resourceType := urlStatus.ResourceType
+		if resourceType == "" {
+			resourceType = models.ResourceAnchor
+		}
+		if len(resourceTypes) == 0 || slices.Contains(resourceTypes, resourceType) {
+			filtered = append(filtered, urlStatus)
+		}
+	}
+	return filtered
+}
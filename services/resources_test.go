@@ -0,0 +1,112 @@
+package services
+
+import (
+	"scraper/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHTML_Resources(test_type *testing.T) {
+	htmlContent := `
+		<!DOCTYPE html>
+		<html>
+			<head>
+				<link rel="stylesheet" href="/main.css">
+				<link rel="icon" href="/favicon.ico">
+				<script src="https://cdn.example.net/app.js"></script>
+				<script>inline()</script>
+			</head>
+			<body>
+				<a href="/logo.png">Logo</a>
+				<img src="/logo.png" srcset="/logo.png 1x, /logo@2x.png 2x">
+				<img src="data:image/png;base64,iVBORw0KGgo=">
+				<picture><source srcset="/hero.webp"><img src="/hero.jpg"></picture>
+				<video src="/intro.mp4" poster="/intro.jpg"><track src="/intro.vtt"></video>
+				<iframe src="https://video.example.net/embed/1"></iframe>
+				<form action="/search"><input type="image" src="/go.png"></form>
+			</body>
+		</html>
+	`
+
+	result, err := ParseHTML(strings.NewReader(htmlContent), "http://example.com",
+		ParseOptions{})
+	assert.NoError(test_type, err)
+
+	found := make(map[string][]string)
+	for _, urlStatus := range result.URLs {
+		found[urlStatus.ResourceType+" "+urlStatus.URL] = urlStatus.Origins
+	}
+	assert.Equal(test_type, map[string][]string{
+		"stylesheet http://example.com/main.css":   {"link[href]"},
+		"link http://example.com/favicon.ico":      {"link[href]"},
+		"script https://cdn.example.net/app.js":    {"script[src]"},
+		"anchor http://example.com/logo.png":       {"a[href]"},
+		"image http://example.com/logo.png":        {"img[src]", "img[srcset]"},
+		"image http://example.com/logo@2x.png":     {"img[srcset]"},
+		"image http://example.com/hero.webp":       {"source[srcset]"},
+		"image http://example.com/hero.jpg":        {"img[src]"},
+		"media http://example.com/intro.mp4":       {"video[src]"},
+		"image http://example.com/intro.jpg":       {"video[poster]"},
+		"media http://example.com/intro.vtt":       {"track[src]"},
+		"iframe https://video.example.net/embed/1": {"iframe[src]"},
+		"form http://example.com/search":           {"form[action]"},
+		"image http://example.com/go.png":          {"input[src]"},
+	}, found)
+
+	assert.Equal(test_type, map[string]int{
+		models.ResourceAnchor:     1,
+		models.ResourceImage:      6,
+		models.ResourceStylesheet: 1,
+		models.ResourceScript:     1,
+		models.ResourceFrame:      1,
+		models.ResourceMedia:      2,
+		models.ResourceLink:       1,
+		models.ResourceForm:       1,
+	}, result.ResourceCounts)
+	// Only anchors are links of the page.
+	assert.Equal(test_type, 1, result.InternalURLsCount)
+	assert.Equal(test_type, 0, result.ExternalURLsCount)
+	assert.Equal(test_type, map[string]int{"http": 1}, result.SchemeCounts)
+}
+
+func TestParseSrcset(test_type *testing.T) {
+	tests := []struct {
+		name     string
+		srcset   string
+		expected []string
+	}{
+		{name: "Density Descriptors", srcset: "a.png 1x, b.png 2x",
+			expected: []string{"a.png", "b.png"}},
+		{name: "Width Descriptors", srcset: " a.png 480w,\n b.png 800w ",
+			expected: []string{"a.png", "b.png"}},
+		{name: "Without Descriptors", srcset: "a.png, b.png", expected: []string{"a.png", "b.png"}},
+		{name: "Comma In URL", srcset: "image.php?size=1,2 1x",
+			expected: []string{"image.php?size=1,2"}},
+		{name: "Empty", srcset: " ", expected: nil},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			assert.Equal(test_type, test_data.expected, parseSrcset(test_data.srcset))
+		})
+	}
+}
+
+func TestFilterURLsByType(test_type *testing.T) {
+	urls := []models.URLStatus{
+		{URL: "http://example.com/", ResourceType: models.ResourceAnchor},
+		{URL: "http://example.com/a.png", ResourceType: models.ResourceImage},
+		{URL: "http://example.com/old"},
+		{URL: "http://example.com/a.js", ResourceType: models.ResourceScript},
+	}
+
+	assert.Equal(test_type, []models.URLStatus{urls[0], urls[2]},
+		FilterURLsByType(urls, DefaultResourceTypes))
+	assert.Equal(test_type, []models.URLStatus{urls[1], urls[3]},
+		FilterURLsByType(urls, []string{models.ResourceImage, models.ResourceScript}))
+	assert.Equal(test_type, urls, FilterURLsByType(urls, nil))
+	assert.Equal(test_type, []models.URLStatus{},
+		FilterURLsByType(urls, []string{models.ResourceForm}))
+}
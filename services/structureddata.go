@@ -0,0 +1,334 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"scraper/models"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Formats structured data is embedded into pages with.
+const (
+	FormatJSONLD    = "json-ld"
+	FormatMicrodata = "microdata"
+	FormatRDFa      = "rdfa"
+)
+
+// Prefixes of the schema.org vocabulary, which are dropped from types and property names.
+var schemaOrgPrefixes = []string{"http://schema.org/", "https://schema.org/", "schema:"}
+
+// This is to extract schema.org style structured data embedded as JSON-LD, Microdata or RDFa.
+// Entities of every format are normalised to the same shape, and malformed blocks are reported
+// as errors rather than failing the whole page.
+func extractStructuredData(doc *html.Node, linkBaseURL string) models.StructuredData {
+	structuredData := models.StructuredData{Entities: []models.StructuredEntity{}}
+	jsonLDBlock := 0
+
+	var visit func(node *html.Node)
+	visit = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch {
+			case node.Data == "script" && isJSONLD(getAttr(node, "type")):
+				jsonLDBlock++
+				extractJSONLD(&structuredData, extractRawText(node), jsonLDBlock)
+				return
+			case hasAttr(node, "itemscope") && !hasAttr(node, "itemprop"):
+				structuredData.Entities = append(structuredData.Entities,
+					extractMicrodataItem(node, linkBaseURL))
+			case hasAttr(node, "typeof") && !hasAttr(node, "property"):
+				structuredData.Entities = append(structuredData.Entities,
+					extractRDFaEntity(node, linkBaseURL))
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(doc)
+
+	return structuredData
+}
+
+// This is to check if a script type attribute declares JSON-LD.
+func isJSONLD(scriptType string) bool {
+	mediaType, _, err := mime.ParseMediaType(scriptType)
+	return err == nil && mediaType == "application/ld+json"
+}
+
+// This is to get the raw text of an element, such as the content of a script.
+func extractRawText(node *html.Node) string {
+	var text strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			text.WriteString(child.Data)
+		}
+	}
+	return text.String()
+}
+
+// This is to extract the entities of a JSON-LD block.
+// Blocks are numbered in the order of the page so that errors can be traced back to them.
+func extractJSONLD(structuredData *models.StructuredData, content string, block int) {
+	var document interface{}
+	if err := json.Unmarshal([]byte(content), &document); err != nil {
+		structuredData.Errors = append(structuredData.Errors, models.StructuredDataError{
+			Format:  FormatJSONLD,
+			Block:   block,
+			Message: fmt.Sprintf("malformed JSON: %v", err),
+		})
+		return
+	}
+
+	var nodes []interface{}
+	switch value := document.(type) {
+	case []interface{}:
+		nodes = value
+	case map[string]interface{}:
+		if graph, found := value["@graph"].([]interface{}); found {
+			nodes = graph
+		} else {
+			nodes = []interface{}{value}
+		}
+	}
+	if len(nodes) == 0 {
+		structuredData.Errors = append(structuredData.Errors, models.StructuredDataError{
+			Format:  FormatJSONLD,
+			Block:   block,
+			Message: "block does not contain any object",
+		})
+	}
+
+	for _, node := range nodes {
+		object, isObject := node.(map[string]interface{})
+		if !isObject {
+			structuredData.Errors = append(structuredData.Errors, models.StructuredDataError{
+				Format:  FormatJSONLD,
+				Block:   block,
+				Message: fmt.Sprintf("expected an object but found %T", node),
+			})
+			continue
+		}
+		entity := jsonLDEntity(object)
+		if len(entity.Types) == 0 {
+			structuredData.Errors = append(structuredData.Errors, models.StructuredDataError{
+				Format:  FormatJSONLD,
+				Block:   block,
+				Message: "entity without @type",
+			})
+		}
+		structuredData.Entities = append(structuredData.Entities, entity)
+	}
+}
+
+// This is to convert a JSON-LD object to an entity. Nested objects become nested entities.
+func jsonLDEntity(object map[string]interface{}) models.StructuredEntity {
+	entity := models.StructuredEntity{
+		Format:     FormatJSONLD,
+		Properties: make(map[string]interface{}),
+	}
+	for key, value := range object {
+		switch key {
+		case "@context", "@graph":
+		case "@type":
+			entity.Types = jsonLDTypes(value)
+		case "@id":
+			entity.ID, _ = value.(string)
+		default:
+			entity.Properties[normalizeSchemaName(key)] = jsonLDValue(value)
+		}
+	}
+	return entity
+}
+
+func jsonLDTypes(value interface{}) []string {
+	var types []string
+	switch value := value.(type) {
+	case string:
+		types = append(types, normalizeSchemaName(value))
+	case []interface{}:
+		for _, item := range value {
+			if itemType, isString := item.(string); isString {
+				types = append(types, normalizeSchemaName(itemType))
+			}
+		}
+	}
+	return types
+}
+
+func jsonLDValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if literal, found := value["@value"]; found && len(value) <= 3 {
+			// Value objects only wrap a literal with its type or language.
+			return literal
+		}
+		return jsonLDEntity(value)
+	case []interface{}:
+		values := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			values = append(values, jsonLDValue(item))
+		}
+		return values
+	}
+	return value
+}
+
+// This is to extract a Microdata item and its properties.
+func extractMicrodataItem(node *html.Node, linkBaseURL string) models.StructuredEntity {
+	entity := models.StructuredEntity{
+		Format:     FormatMicrodata,
+		Types:      normalizeSchemaNames(strings.Fields(getAttr(node, "itemtype"))),
+		ID:         strings.TrimSpace(getAttr(node, "itemid")),
+		Properties: make(map[string]interface{}),
+	}
+
+	var visit func(node *html.Node)
+	visit = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if hasAttr(child, "itemprop") {
+				var value interface{}
+				if hasAttr(child, "itemscope") {
+					value = extractMicrodataItem(child, linkBaseURL)
+				} else {
+					value = microdataValue(child, linkBaseURL)
+				}
+				for _, name := range strings.Fields(getAttr(child, "itemprop")) {
+					addProperty(entity.Properties, normalizeSchemaName(name), value)
+				}
+			}
+			// Properties of nested items belong to them rather than to this item.
+			if !hasAttr(child, "itemscope") {
+				visit(child)
+			}
+		}
+	}
+	visit(node)
+
+	return entity
+}
+
+// This is to get the value of a Microdata property, which depends on the element it is on.
+func microdataValue(node *html.Node, linkBaseURL string) interface{} {
+	switch node.Data {
+	case "meta":
+		return getAttr(node, "content")
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return resolveReference(linkBaseURL, getAttr(node, "src"))
+	case "a", "area", "link":
+		return resolveReference(linkBaseURL, getAttr(node, "href"))
+	case "object":
+		return resolveReference(linkBaseURL, getAttr(node, "data"))
+	case "data", "meter":
+		return getAttr(node, "value")
+	case "time":
+		if hasAttr(node, "datetime") {
+			return getAttr(node, "datetime")
+		}
+	}
+	return extractText(node)
+}
+
+// This is to extract an RDFa entity and its properties.
+// Only the basics are supported: typeof, property, resource/about, content and links.
+func extractRDFaEntity(node *html.Node, linkBaseURL string) models.StructuredEntity {
+	entity := models.StructuredEntity{
+		Format:     FormatRDFa,
+		Types:      normalizeSchemaNames(strings.Fields(getAttr(node, "typeof"))),
+		Properties: make(map[string]interface{}),
+	}
+	for _, key := range []string{"resource", "about"} {
+		if hasAttr(node, key) && entity.ID == "" {
+			entity.ID = resolveReference(linkBaseURL, getAttr(node, key))
+		}
+	}
+
+	var visit func(node *html.Node)
+	visit = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if hasAttr(child, "property") {
+				var value interface{}
+				if hasAttr(child, "typeof") {
+					value = extractRDFaEntity(child, linkBaseURL)
+				} else {
+					value = rdfaValue(child, linkBaseURL)
+				}
+				for _, name := range strings.Fields(getAttr(child, "property")) {
+					addProperty(entity.Properties, normalizeSchemaName(name), value)
+				}
+			}
+			// Properties of nested entities belong to them rather than to this entity.
+			if !hasAttr(child, "typeof") {
+				visit(child)
+			}
+		}
+	}
+	visit(node)
+
+	return entity
+}
+
+// This is to get the value of an RDFa property.
+func rdfaValue(node *html.Node, linkBaseURL string) interface{} {
+	switch {
+	case hasAttr(node, "content"):
+		return getAttr(node, "content")
+	case hasAttr(node, "resource"):
+		return resolveReference(linkBaseURL, getAttr(node, "resource"))
+	case hasAttr(node, "href"):
+		return resolveReference(linkBaseURL, getAttr(node, "href"))
+	case hasAttr(node, "src"):
+		return resolveReference(linkBaseURL, getAttr(node, "src"))
+	}
+	return extractText(node)
+}
+
+// This is to add a property value, turning repeated properties into a list of values.
+func addProperty(properties map[string]interface{}, name string, value interface{}) {
+	existing, found := properties[name]
+	if !found {
+		properties[name] = value
+		return
+	}
+	if values, isList := existing.([]interface{}); isList {
+		properties[name] = append(values, value)
+	} else {
+		properties[name] = []interface{}{existing, value}
+	}
+}
+
+// This is to resolve a reference found in structured data, keeping it as is if it is invalid.
+func resolveReference(linkBaseURL, reference string) string {
+	reference = strings.TrimSpace(reference)
+	if reference == "" || !isValidReference(reference) {
+		return reference
+	}
+	return resolveURL(linkBaseURL, reference)
+}
+
+// This is to shorten schema.org types and property names, like "https://schema.org/Product"
+// to "Product". Names from other vocabularies are kept as they are.
+func normalizeSchemaName(name string) string {
+	name = strings.TrimSpace(name)
+	for _, prefix := range schemaOrgPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimPrefix(name, prefix)
+		}
+	}
+	return name
+}
+
+func normalizeSchemaNames(names []string) []string {
+	for i, name := range names {
+		names[i] = normalizeSchemaName(name)
+	}
+	return names
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"scraper/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestExtractStructuredData(test_type *testing.T) {
+	tests := []struct {
+		name             string
+		htmlContent      string
+		expectedEntities []models.StructuredEntity
+		expectedErrors   []models.StructuredDataError
+	}{
+		{
+			name: "JSON-LD",
+			htmlContent: `
+				<script type="application/ld+json">
+					{
+						"@context": "https://schema.org",
+						"@type": "Product",
+						"name": "Shoe",
+						"offers": {"@type": "Offer", "price": 10.5},
+						"brand": {"@value": "Acme", "@language": "en"}
+					}
+				</script>
+				<script type="application/ld+json; charset=utf-8">
+					{"@context": "https://schema.org", "@graph": [
+						{"@type": ["Organization", "https://schema.org/Brand"], "@id": "#acme"}
+					]}
+				</script>
+			`,
+			expectedEntities: []models.StructuredEntity{
+				{
+					Format: FormatJSONLD,
+					Types:  []string{"Product"},
+					Properties: map[string]interface{}{
+						"name": "Shoe",
+						"offers": models.StructuredEntity{
+							Format:     FormatJSONLD,
+							Types:      []string{"Offer"},
+							Properties: map[string]interface{}{"price": 10.5},
+						},
+						"brand": "Acme",
+					},
+				},
+				{
+					Format:     FormatJSONLD,
+					Types:      []string{"Organization", "Brand"},
+					ID:         "#acme",
+					Properties: map[string]interface{}{},
+				},
+			},
+		},
+		{
+			name: "Malformed JSON-LD",
+			htmlContent: `
+				<script type="application/ld+json">{"@type": "Article",}</script>
+				<script type="application/ld+json">{"headline": "Untyped"}</script>
+				<script type="application/ld+json">"text"</script>
+			`,
+			expectedEntities: []models.StructuredEntity{
+				{
+					Format:     FormatJSONLD,
+					Properties: map[string]interface{}{"headline": "Untyped"},
+				},
+			},
+			expectedErrors: []models.StructuredDataError{
+				{Format: FormatJSONLD, Block: 1,
+					Message: "malformed JSON: invalid character '}' looking for beginning of " +
+						"object key string"},
+				{Format: FormatJSONLD, Block: 2, Message: "entity without @type"},
+				{Format: FormatJSONLD, Block: 3, Message: "block does not contain any object"},
+			},
+		},
+		{
+			name: "Microdata",
+			htmlContent: `
+				<div itemscope itemtype="https://schema.org/Product" itemid="urn:isbn:1">
+					<h1 itemprop="name">Shoe</h1>
+					<img itemprop="image" src="/shoe.png">
+					<span itemprop="color">Red</span>
+					<span itemprop="color">Blue</span>
+					<div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+						<meta itemprop="price" content="10.50">
+						<time itemprop="validFrom" datetime="2024-01-01">New Year</time>
+					</div>
+				</div>
+			`,
+			expectedEntities: []models.StructuredEntity{
+				{
+					Format: FormatMicrodata,
+					Types:  []string{"Product"},
+					ID:     "urn:isbn:1",
+					Properties: map[string]interface{}{
+						"name":  "Shoe",
+						"image": "http://example.com/shoe.png",
+						"color": []interface{}{"Red", "Blue"},
+						"offers": models.StructuredEntity{
+							Format: FormatMicrodata,
+							Types:  []string{"Offer"},
+							Properties: map[string]interface{}{
+								"price":     "10.50",
+								"validFrom": "2024-01-01",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RDFa",
+			htmlContent: `
+				<div vocab="https://schema.org/" typeof="Person" resource="#jane">
+					<span property="name">Jane</span>
+					<a property="url" href="/jane">Profile</a>
+					<div property="address" typeof="PostalAddress">
+						<span property="addressLocality">Amsterdam</span>
+					</div>
+					<meta property="schema:jobTitle" content="Engineer">
+				</div>
+			`,
+			expectedEntities: []models.StructuredEntity{
+				{
+					Format: FormatRDFa,
+					Types:  []string{"Person"},
+					ID:     "http://example.com/#jane",
+					Properties: map[string]interface{}{
+						"name": "Jane",
+						"url":  "http://example.com/jane",
+						"address": models.StructuredEntity{
+							Format:     FormatRDFa,
+							Types:      []string{"PostalAddress"},
+							Properties: map[string]interface{}{"addressLocality": "Amsterdam"},
+						},
+						"jobTitle": "Engineer",
+					},
+				},
+			},
+		},
+		{
+			name:             "No Structured Data",
+			htmlContent:      `<p>Plain page</p>`,
+			expectedEntities: []models.StructuredEntity{},
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			doc, err := html.Parse(strings.NewReader(test_data.htmlContent))
+			assert.NoError(test_type, err)
+
+			result := extractStructuredData(doc, "http://example.com/")
+			assert.Equal(test_type, test_data.expectedEntities, result.Entities)
+			assert.Equal(test_type, test_data.expectedErrors, result.Errors)
+		})
+	}
+}
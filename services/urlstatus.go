@@ -1,49 +1,366 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"scraper/config"
 	"scraper/logger"
 	"scraper/models"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 // This is to check the URL status and decide wether it is accessible or not.
 // It marks the status of each collected URL.
 // Since the URL collection can be huge we check status based on given start and end positions.
 func CheckURLStatus(client *http.Client, urls []models.URLStatus, start, end int) int {
+	return CheckURLStatusWithProgress(client, urls, start, end, nil)
+}
+
+// This is to check the URL status same as CheckURLStatus while reporting progress.
+// The given callback is invoked with the index of each URL as soon as it is checked.
+// URLs are checked by a bounded number of workers, and outgoing requests are limited
+// process wide by the shared URL check limiter.
+func CheckURLStatusWithProgress(client *http.Client, urls []models.URLStatus, start, end int,
+	onChecked func(idx int)) int {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var inaccessibleCount int
 
-	for i := start; i < end; i++ {
+	indexes := make(chan int)
+	workers := min(max(config.GetURLCheckWorkerPoolSize(), 1), end-start)
+	for worker := 0; worker < workers; worker++ {
 		wg.Add(1)
 
-		go func(idx int) {
+		go func() {
 			defer wg.Done()
 
-			resp, err := client.Get(urls[idx].URL)
-			if err != nil {
-				logger.Error(err)
+			for idx := range indexes {
+				if !checkURL(client, &urls[idx]) {
+					mu.Lock()
+					inaccessibleCount++
+					mu.Unlock()
+				}
+				if onChecked != nil {
+					onChecked(idx)
+				}
+			}
+		}()
+	}
 
-				mu.Lock()
-				inaccessibleCount++
-				mu.Unlock()
+	for i := start; i < end; i++ {
+		indexes <- i
+	}
+	close(indexes)
 
-				urls[idx].Error = err.Error()
-				return
-			}
+	wg.Wait()
+	return inaccessibleCount
+}
 
-			defer resp.Body.Close()
-			urls[idx].HTTPStatus = resp.StatusCode
+// This is to check the status of a single URL and record it.
+// Transient failures are retried with an exponential backoff. Returns false if the URL is
+// not accessible.
+func checkURL(client *http.Client, urlStatus *models.URLStatus) bool {
+	retries := max(config.GetURLCheckMaxRetries(), 0)
+	for attempt := 1; ; attempt++ {
+		urlStatus.Attempts = attempt
+		retryable, retryAfter := checkURLOnce(client, urlStatus)
+		if !retryable || attempt > retries {
+			break
+		}
+		sleep(retryDelay(attempt, retryAfter))
+	}
 
-			if resp.StatusCode < 200 || resp.StatusCode > 299 {
-				mu.Lock()
-				inaccessibleCount++
-				mu.Unlock()
-			}
-		}(i)
+	return urlStatus.Error == "" && urlStatus.HTTPStatus >= 200 && urlStatus.HTTPStatus <= 299
+}
+
+// This is to make a single attempt to check the status of the URL and record it.
+// Returns whether the failure is worth retrying, and the delay requested by the server if any.
+func checkURLOnce(client *http.Client, urlStatus *models.URLStatus) (bool, time.Duration) {
+	release := limiter.acquire(urlHost(urlStatus.URL))
+	defer release()
+
+	requestedAt := time.Now()
+	resp, trace, err := requestURL(client, urlStatus.URL)
+	checkedAt := time.Now()
+	urlStatus.CheckedAt = &checkedAt
+	urlStatus.LatencyMs = checkedAt.Sub(requestedAt).Milliseconds()
+	recordTrace(urlStatus, trace)
+	if err != nil {
+		logger.Error(err)
+		urlStatus.HTTPStatus = 0
+		urlStatus.Error = err.Error()
+		urlStatus.ErrorCode = classifyError(err)
+		return isTransient(err), 0
 	}
 
-	wg.Wait()
+	defer resp.Body.Close()
+	urlStatus.HTTPStatus = resp.StatusCode
+	urlStatus.Error = ""
+	urlStatus.ErrorCode = classifyStatus(resp.StatusCode)
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true, parseRetryAfter(resp.Header.Get("Retry-After"))
+	}
+	return false, 0
+}
+
+// This is to pause between attempts. Replaced in tests to avoid waiting.
+var sleep = time.Sleep
+
+// This is to check if the request error may not happen again on the next attempt.
+func isTransient(err error) bool {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	switch {
+	case isPermanent(err):
+		return false
+	case errors.As(err, &dnsErr):
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	return isTimeout(err) || errors.As(err, &opErr) || errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// This is to read the Retry-After header, given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// This is to calculate how long to wait before the next attempt.
+// Delay requested by the server is honoured, otherwise the delay doubles with each attempt
+// with a random jitter. Either way it never exceeds the configured maximum.
+func retryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	maxDelay := time.Duration(config.GetURLCheckRetryMaxDelay()) * time.Millisecond
+	if retryAfter > 0 {
+		return min(retryAfter, maxDelay)
+	}
+
+	backoff := time.Duration(config.GetURLCheckRetryBaseDelay()) * time.Millisecond
+	for i := 1; i < attempt && backoff < maxDelay; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maxDelay)
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// Information about how a URL was requested.
+type requestTrace struct {
+	method    string
+	redirects []models.RedirectHop
+	finalURL  string
+	loop      bool
+}
+
+var (
+	errRedirectLoop      = errors.New("redirect loop detected")
+	errTooManyRedirects  = errors.New("too many redirects")
+	errMissingRedirectTo = errors.New("redirect without a valid location")
+	errInvalidURL        = errors.New("invalid URL")
+)
+
+// This is to check if the request error is caused by the URL or its redirects, so that
+// requesting it again in any way would fail the same way.
+func isPermanent(err error) bool {
+	return errors.Is(err, errRedirectLoop) || errors.Is(err, errTooManyRedirects) ||
+		errors.Is(err, errMissingRedirectTo) || errors.Is(err, errInvalidURL)
+}
+
+// This is to request the URL without downloading its body.
+// HEAD is tried first, and a GET limited to the first byte is used for hosts which do not
+// support HEAD. Caller is responsible for closing the response body.
+func requestURL(client *http.Client, rawURL string) (*http.Response, requestTrace, error) {
+	host := urlHost(rawURL)
+	if !headUnsupported.contains(host) {
+		resp, trace, err := sendRequest(client, http.MethodHead, rawURL, false)
+		switch {
+		case err == nil && resp.StatusCode != http.StatusMethodNotAllowed &&
+			resp.StatusCode != http.StatusNotImplemented:
+			return resp, trace, nil
+		case err == nil:
+			resp.Body.Close()
+			headUnsupported.add(host)
+		case isTimeout(err) || isPermanent(err):
+			// Retrying with GET would only end up the same way.
+			return nil, trace, err
+		}
+	}
+
+	resp, trace, err := sendRequest(client, http.MethodGet, rawURL, true)
+	if err == nil && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// Empty resources can not serve the first byte, so request them as a whole.
+		resp.Body.Close()
+		resp, trace, err = sendRequest(client, http.MethodGet, rawURL, false)
+	}
+	return resp, trace, err
+}
+
+// This is to send a request to the URL, optionally asking only for the first byte.
+// Redirects are followed one by one up to the configured maximum and recorded as hops.
+func sendRequest(client *http.Client, method, rawURL string,
+	firstByteOnly bool) (*http.Response, requestTrace, error) {
+	trace := requestTrace{method: method}
+
+	noRedirectClient := *client
+	noRedirectClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	visited := map[string]struct{}{rawURL: {}}
+	currentURL := rawURL
+	for {
+		if err := validateURL(currentURL); err != nil {
+			return nil, trace, err
+		}
+		req, err := http.NewRequest(method, currentURL, nil)
+		if err != nil {
+			return nil, trace, fmt.Errorf("%w: %v", errInvalidURL, err)
+		}
+		if firstByteOnly {
+			req.Header.Set("Range", "bytes=0-0")
+		}
+
+		resp, err := noRedirectClient.Do(req)
+		if err != nil || !isRedirect(resp.StatusCode) {
+			return resp, trace, err
+		}
+		resp.Body.Close()
+
+		location, err := req.URL.Parse(resp.Header.Get("Location"))
+		if err != nil || resp.Header.Get("Location") == "" {
+			return nil, trace, fmt.Errorf("%w: %s", errMissingRedirectTo, currentURL)
+		}
+		trace.redirects = append(trace.redirects, models.RedirectHop{
+			URL:        currentURL,
+			HTTPStatus: resp.StatusCode,
+			Location:   location.String(),
+		})
+		trace.finalURL = location.String()
+
+		if _, seen := visited[trace.finalURL]; seen {
+			trace.loop = true
+			return nil, trace, fmt.Errorf("%w: %s", errRedirectLoop, trace.finalURL)
+		}
+		if len(trace.redirects) > config.GetURLCheckMaxRedirects() {
+			return nil, trace, fmt.Errorf("%w: stopped after %d redirects", errTooManyRedirects,
+				config.GetURLCheckMaxRedirects())
+		}
+		visited[trace.finalURL] = struct{}{}
+		currentURL = trace.finalURL
+	}
+}
+
+// This is to check if the status code asks to follow the location.
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// This is to record how the URL was requested and flag suspicious redirects.
+func recordTrace(urlStatus *models.URLStatus, trace requestTrace) {
+	urlStatus.Method = trace.method
+	urlStatus.Redirects = trace.redirects
+	urlStatus.FinalURL = trace.finalURL
+	urlStatus.RedirectLoop = trace.loop
+	urlStatus.CrossDomainRedirect = false
+	urlStatus.InsecureRedirect = false
+
+	for _, hop := range trace.redirects {
+		if !isInternal(urlStatus.URL, hop.Location) {
+			urlStatus.CrossDomainRedirect = true
+		}
+		if strings.HasPrefix(hop.URL, "https://") && strings.HasPrefix(hop.Location, "http://") {
+			urlStatus.InsecureRedirect = true
+		}
+	}
+}
+
+// This is to check if the error is caused by a timeout.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Hosts which are known to not support HEAD requests.
+var headUnsupported = hostSet{hosts: make(map[string]struct{})}
+
+// Maximum number of hosts remembered by a host set before it starts over.
+const maxHostSetSize = 10000
+
+type hostSet struct {
+	sync.RWMutex
+	hosts map[string]struct{}
+}
+
+func (set *hostSet) contains(host string) bool {
+	set.RLock()
+	defer set.RUnlock()
+
+	_, found := set.hosts[host]
+	return found
+}
+
+func (set *hostSet) add(host string) {
+	set.Lock()
+	defer set.Unlock()
+
+	if len(set.hosts) >= maxHostSetSize {
+		set.hosts = make(map[string]struct{})
+	}
+	set.hosts[host] = struct{}{}
+}
+
+// This is to check if all given URLs already have a recorded status.
+func IsURLStatusChecked(urls []models.URLStatus) bool {
+	for _, urlStatus := range urls {
+		if urlStatus.CheckedAt == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// This is to count inaccessible URLs out of already checked URLs.
+func CountInaccessibleURLs(urls []models.URLStatus) int {
+	_, inaccessibleCount, _ := SummarizeURLStatus(urls)
 	return inaccessibleCount
 }
+
+// This is to count accessible, inaccessible and not yet checked URLs.
+func SummarizeURLStatus(urls []models.URLStatus) (accessible, inaccessible, pending int) {
+	for _, urlStatus := range urls {
+		switch {
+		case urlStatus.CheckedAt == nil:
+			pending++
+		case urlStatus.Error != "" || urlStatus.HTTPStatus < 200 || urlStatus.HTTPStatus > 299:
+			inaccessible++
+		default:
+			accessible++
+		}
+	}
+	return accessible, inaccessible, pending
+}
@@ -1,10 +1,15 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"scraper/config"
 	"scraper/models"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -41,4 +46,366 @@ func TestCheckURLStatus(test_type *testing.T) {
 	assert.Equal(test_type, 2, inaccessibleCount, "The count of inaccessible URLs should be 2")
 	assert.NotNil(test_type, urls[2].Error, "Expected an error for the network failure URL")
 	assert.Equal(test_type, 404, urls[1].HTTPStatus, "Expected 404 status for the invalid URL")
+	assert.True(test_type, IsURLStatusChecked(urls), "Expected all URLs to be marked as checked")
+	assert.Equal(test_type, 2, CountInaccessibleURLs(urls),
+		"Expected recorded statuses to give the same inaccessible count")
+}
+
+func TestIsURLStatusChecked(test_type *testing.T) {
+	checkedAt := time.Now()
+	urls := []models.URLStatus{
+		{URL: "http://example.com/valid", HTTPStatus: 200, CheckedAt: &checkedAt},
+		{URL: "http://example.com/unchecked"},
+	}
+
+	assert.True(test_type, IsURLStatusChecked(urls[:1]))
+	assert.False(test_type, IsURLStatusChecked(urls))
+	assert.Equal(test_type, 0, CountInaccessibleURLs(urls),
+		"Unchecked URLs should not be counted as inaccessible")
+}
+
+func TestCheckURLStatusWithProgress(test_type *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	client := &http.Client{
+		Transport: httpmock.DefaultTransport,
+	}
+	httpmock.RegisterNoResponder(httpmock.NewStringResponder(200, "OK"))
+
+	var urls []models.URLStatus
+	for i := 0; i < 50; i++ {
+		urls = append(urls, models.URLStatus{URL: fmt.Sprintf("http://host-%d.com", i)})
+	}
+
+	var mu sync.Mutex
+	var checked []int
+	inaccessibleCount := CheckURLStatusWithProgress(client, urls, 10, 40, func(idx int) {
+		mu.Lock()
+		checked = append(checked, idx)
+		mu.Unlock()
+	})
+
+	assert.Equal(test_type, 0, inaccessibleCount)
+	assert.Len(test_type, checked, 30, "Each URL in the range should be reported once")
+	assert.Nil(test_type, urls[0].CheckedAt, "URLs out of the range should not be checked")
+	assert.NotNil(test_type, urls[39].CheckedAt)
+}
+
+func TestRequestURL(test_type *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	client := &http.Client{
+		Transport: httpmock.DefaultTransport,
+	}
+
+	// Responds with the given status to HEAD, and to GET with the range it was asked for.
+	rangeResponder := func(headStatus int) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodHead {
+				return httpmock.NewStringResponse(headStatus, ""), nil
+			}
+			resp := httpmock.NewStringResponse(http.StatusOK, "full body")
+			if req.Header.Get("Range") != "" {
+				resp = httpmock.NewStringResponse(http.StatusPartialContent, "f")
+			}
+			return resp, nil
+		}
+	}
+
+	tests := []struct {
+		name           string
+		url            string
+		responder      httpmock.Responder
+		expectedMethod string
+		expectedStatus int
+		expectedCalls  int
+	}{
+		{
+			name:           "HEAD Supported",
+			url:            "http://head.com/video.mp4",
+			responder:      rangeResponder(http.StatusOK),
+			expectedMethod: http.MethodHead,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  1,
+		},
+		{
+			name:           "HEAD Not Allowed",
+			url:            "http://no-head.com/video.mp4",
+			responder:      rangeResponder(http.StatusMethodNotAllowed),
+			expectedMethod: http.MethodGet,
+			expectedStatus: http.StatusPartialContent,
+			expectedCalls:  2,
+		},
+		{
+			name:           "Known Host Without HEAD Support",
+			url:            "http://no-head.com/other.pdf",
+			responder:      rangeResponder(http.StatusMethodNotAllowed),
+			expectedMethod: http.MethodGet,
+			expectedStatus: http.StatusPartialContent,
+			expectedCalls:  1,
+		},
+		{
+			name: "Range Not Satisfiable",
+			url:  "http://empty.com/empty.txt",
+			responder: func(req *http.Request) (*http.Response, error) {
+				switch {
+				case req.Method == http.MethodHead:
+					return httpmock.NewStringResponse(http.StatusNotImplemented, ""), nil
+				case req.Header.Get("Range") != "":
+					return httpmock.NewStringResponse(http.StatusRequestedRangeNotSatisfiable, ""),
+						nil
+				}
+				return httpmock.NewStringResponse(http.StatusOK, ""), nil
+			},
+			expectedMethod: http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  3,
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			httpmock.Reset()
+			calls := 0
+			httpmock.RegisterResponder(http.MethodHead, test_data.url,
+				func(req *http.Request) (*http.Response, error) {
+					calls++
+					return test_data.responder(req)
+				})
+			httpmock.RegisterResponder(http.MethodGet, test_data.url,
+				func(req *http.Request) (*http.Response, error) {
+					calls++
+					return test_data.responder(req)
+				})
+
+			urlStatus := models.URLStatus{URL: test_data.url}
+			checkURL(client, &urlStatus)
+
+			assert.Equal(test_type, test_data.expectedMethod, urlStatus.Method)
+			assert.Equal(test_type, test_data.expectedStatus, urlStatus.HTTPStatus)
+			assert.Equal(test_type, test_data.expectedCalls, calls)
+		})
+	}
+}
+
+func TestCheckURL_Redirects(test_type *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	client := &http.Client{
+		Transport: httpmock.DefaultTransport,
+	}
+
+	// Responds with a redirect to the given location.
+	redirectTo := func(status int, location string) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(status, "")
+			resp.Header.Set("Location", location)
+			return resp, nil
+		}
+	}
+
+	httpmock.RegisterResponder(http.MethodHead, "https://chain.com/old",
+		redirectTo(http.StatusMovedPermanently, "/new"))
+	httpmock.RegisterResponder(http.MethodHead, "https://chain.com/new",
+		redirectTo(http.StatusFound, "https://www.chain.com/final"))
+	httpmock.RegisterResponder(http.MethodHead, "https://www.chain.com/final",
+		httpmock.NewStringResponder(http.StatusOK, ""))
+
+	httpmock.RegisterResponder(http.MethodHead, "https://loop.com/a",
+		redirectTo(http.StatusFound, "https://loop.com/b"))
+	httpmock.RegisterResponder(http.MethodHead, "https://loop.com/b",
+		redirectTo(http.StatusFound, "https://loop.com/a"))
+
+	httpmock.RegisterResponder(http.MethodHead, "https://parked.com/link",
+		redirectTo(http.StatusMovedPermanently, "http://parking-service.net/"))
+	httpmock.RegisterResponder(http.MethodHead, "http://parking-service.net/",
+		httpmock.NewStringResponder(http.StatusOK, ""))
+
+	for i := 0; i <= config.GetURLCheckMaxRedirects(); i++ {
+		httpmock.RegisterResponder(http.MethodHead, fmt.Sprintf("https://endless.com/%d", i),
+			redirectTo(http.StatusTemporaryRedirect, fmt.Sprintf("/%d", i+1)))
+	}
+
+	test_type.Run("Redirect Chain", func(test_type *testing.T) {
+		urlStatus := models.URLStatus{URL: "https://chain.com/old"}
+		assert.True(test_type, checkURL(client, &urlStatus))
+
+		assert.Equal(test_type, http.StatusOK, urlStatus.HTTPStatus)
+		assert.Equal(test_type, []models.RedirectHop{
+			{URL: "https://chain.com/old", HTTPStatus: http.StatusMovedPermanently,
+				Location: "https://chain.com/new"},
+			{URL: "https://chain.com/new", HTTPStatus: http.StatusFound,
+				Location: "https://www.chain.com/final"},
+		}, urlStatus.Redirects)
+		assert.Equal(test_type, "https://www.chain.com/final", urlStatus.FinalURL)
+		assert.False(test_type, urlStatus.RedirectLoop)
+		assert.False(test_type, urlStatus.CrossDomainRedirect)
+		assert.False(test_type, urlStatus.InsecureRedirect)
+	})
+
+	test_type.Run("Redirect Loop", func(test_type *testing.T) {
+		urlStatus := models.URLStatus{URL: "https://loop.com/a"}
+		assert.False(test_type, checkURL(client, &urlStatus))
+
+		assert.True(test_type, urlStatus.RedirectLoop)
+		assert.Len(test_type, urlStatus.Redirects, 2)
+		assert.Contains(test_type, urlStatus.Error, "redirect loop")
+	})
+
+	test_type.Run("Cross Domain Insecure Redirect", func(test_type *testing.T) {
+		urlStatus := models.URLStatus{URL: "https://parked.com/link"}
+		assert.True(test_type, checkURL(client, &urlStatus))
+
+		assert.Equal(test_type, "http://parking-service.net/", urlStatus.FinalURL)
+		assert.True(test_type, urlStatus.CrossDomainRedirect)
+		assert.True(test_type, urlStatus.InsecureRedirect)
+	})
+
+	test_type.Run("Too Many Redirects", func(test_type *testing.T) {
+		urlStatus := models.URLStatus{URL: "https://endless.com/0"}
+		assert.False(test_type, checkURL(client, &urlStatus))
+
+		assert.Len(test_type, urlStatus.Redirects, config.GetURLCheckMaxRedirects()+1)
+		assert.False(test_type, urlStatus.RedirectLoop)
+		assert.Contains(test_type, urlStatus.Error, "too many redirects")
+	})
+}
+
+func TestCheckURL_Retries(test_type *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	client := &http.Client{
+		Transport: httpmock.DefaultTransport,
+	}
+
+	var delays []time.Duration
+	originalSleep := sleep
+	sleep = func(delay time.Duration) {
+		delays = append(delays, delay)
+	}
+	defer func() { sleep = originalSleep }()
+
+	// Responds with the given responses in order, repeating the last one.
+	sequence := func(responses ...func() (*http.Response, error)) httpmock.Responder {
+		calls := 0
+		return func(req *http.Request) (*http.Response, error) {
+			resp := responses[min(calls, len(responses)-1)]
+			calls++
+			return resp()
+		}
+	}
+	status := func(code int, retryAfter string) func() (*http.Response, error) {
+		return func() (*http.Response, error) {
+			resp := httpmock.NewStringResponse(code, "")
+			if retryAfter != "" {
+				resp.Header.Set("Retry-After", retryAfter)
+			}
+			return resp, nil
+		}
+	}
+	connectionReset := func() (*http.Response, error) {
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}
+	}
+
+	tests := []struct {
+		name               string
+		url                string
+		responder          httpmock.Responder
+		expectedAccessible bool
+		expectedAttempts   int
+		expectedStatus     int
+		expectedDelays     []time.Duration
+	}{
+		{
+			name:               "Accessible On First Attempt",
+			url:                "http://ok.com/",
+			responder:          sequence(status(http.StatusOK, "")),
+			expectedAccessible: true,
+			expectedAttempts:   1,
+			expectedStatus:     http.StatusOK,
+		},
+		{
+			name:               "Not Found Is Not Retried",
+			url:                "http://dead.com/",
+			responder:          sequence(status(http.StatusNotFound, "")),
+			expectedAccessible: false,
+			expectedAttempts:   1,
+			expectedStatus:     http.StatusNotFound,
+		},
+		{
+			name: "Too Many Requests With Retry After",
+			url:  "http://busy.com/",
+			responder: sequence(status(http.StatusTooManyRequests, "3"),
+				status(http.StatusOK, "")),
+			expectedAccessible: true,
+			expectedAttempts:   2,
+			expectedStatus:     http.StatusOK,
+			expectedDelays:     []time.Duration{3 * time.Second},
+		},
+		{
+			name:               "Unavailable On Every Attempt",
+			url:                "http://down.com/",
+			responder:          sequence(status(http.StatusServiceUnavailable, "1")),
+			expectedAccessible: false,
+			expectedAttempts:   config.GetURLCheckMaxRetries() + 1,
+			expectedStatus:     http.StatusServiceUnavailable,
+		},
+		{
+			name: "Transient Network Error",
+			url:  "http://flaky.com/",
+			responder: sequence(connectionReset, connectionReset,
+				status(http.StatusOK, "")),
+			expectedAccessible: true,
+			expectedAttempts:   2,
+			expectedStatus:     http.StatusOK,
+		},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.name, func(test_type *testing.T) {
+			delays = nil
+			// Failed HEAD requests fall back to GET within the same attempt.
+			httpmock.RegisterResponder(http.MethodHead, test_data.url, test_data.responder)
+			httpmock.RegisterResponder(http.MethodGet, test_data.url, test_data.responder)
+
+			urlStatus := models.URLStatus{URL: test_data.url}
+			accessible := checkURL(client, &urlStatus)
+
+			assert.Equal(test_type, test_data.expectedAccessible, accessible)
+			assert.Equal(test_type, test_data.expectedAttempts, urlStatus.Attempts)
+			assert.Equal(test_type, test_data.expectedStatus, urlStatus.HTTPStatus)
+			assert.Equal(test_type, classifyStatus(test_data.expectedStatus), urlStatus.ErrorCode)
+			assert.Len(test_type, delays, test_data.expectedAttempts-1)
+			if test_data.expectedDelays != nil {
+				assert.Equal(test_type, test_data.expectedDelays, delays)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(test_type *testing.T) {
+	baseDelay := time.Duration(config.GetURLCheckRetryBaseDelay()) * time.Millisecond
+	maxDelay := time.Duration(config.GetURLCheckRetryMaxDelay()) * time.Millisecond
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		backoff := baseDelay << (attempt - 1)
+		delay := retryDelay(attempt, 0)
+		assert.GreaterOrEqual(test_type, delay, backoff/2)
+		assert.LessOrEqual(test_type, delay, backoff)
+	}
+
+	assert.LessOrEqual(test_type, retryDelay(100, 0), maxDelay)
+	assert.Equal(test_type, 2*time.Second, retryDelay(1, 2*time.Second))
+	assert.Equal(test_type, maxDelay, retryDelay(1, time.Hour))
+}
+
+func TestParseRetryAfter(test_type *testing.T) {
+	assert.Equal(test_type, 120*time.Second, parseRetryAfter("120"))
+	assert.Equal(test_type, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(test_type, time.Duration(0), parseRetryAfter("soon"))
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	delay := parseRetryAfter(date)
+	assert.Greater(test_type, delay, 50*time.Second)
+	assert.LessOrEqual(test_type, delay, time.Minute)
 }
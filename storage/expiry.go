@@ -0,0 +1,263 @@
+// This is a storage decorator which bounds the lifetime and memory usage of stored data.
+// Each entry expires after the configured TTL, and once the maximum entry count or byte
+// budget is exceeded the least recently used entries are evicted.
+// IDs of expired and evicted entries are remembered for a while so that they can be
+// reported as gone instead of unknown.
+package storage
+
+import (
+	"container/list"
+	"encoding/json"
+	"scraper/logger"
+	"scraper/models"
+	"sync"
+	"time"
+)
+
+// Default lifetime of a remembered expired ID when entries never expire on their own.
+const defaultTombstoneTTL = 24 * time.Hour
+
+// Book keeping information of a single stored entry.
+type expiryEntry struct {
+	id        string
+	size      int64
+	expiresAt time.Time
+}
+
+type ExpiringStore struct {
+	sync.Mutex
+	inner      Store
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+	usedBytes  int64
+	lru        *list.List
+	entries    map[string]*list.Element
+	expired    map[string]time.Time
+	now        func() time.Time
+}
+
+// This is to wrap the given storage backend with TTL and memory bounds.
+// Zero TTL, maxEntries or maxBytes disables the respective limit.
+func NewExpiringStore(inner Store, ttl time.Duration, maxEntries int,
+	maxBytes int64) *ExpiringStore {
+	expiringStore := &ExpiringStore{
+		inner:      inner,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		expired:    make(map[string]time.Time),
+		now:        time.Now,
+	}
+
+	// Entries already persisted by the backend start their lifetime from now.
+	expiringStore.Lock()
+	defer expiringStore.Unlock()
+	for _, id := range inner.List() {
+		if info, exists := inner.Retrieve(id); exists {
+			expiringStore.track(id, info)
+		}
+	}
+	expiringStore.enforceBounds()
+	return expiringStore
+}
+
+// This is to store page info under the given ID.
+func (expiringStore *ExpiringStore) Store(id string, info models.PageInfo) error {
+	expiringStore.Lock()
+	defer expiringStore.Unlock()
+
+	if err := expiringStore.inner.Store(id, info); err != nil {
+		return err
+	}
+	expiringStore.forget(id)
+	expiringStore.track(id, info)
+	expiringStore.enforceBounds()
+	return nil
+}
+
+// This is to retrieve page info by ID if it has not expired yet.
+func (expiringStore *ExpiringStore) Retrieve(id string) (models.PageInfo, bool) {
+	expiringStore.Lock()
+	defer expiringStore.Unlock()
+
+	element, exists := expiringStore.entries[id]
+	if !exists {
+		return models.PageInfo{}, false
+	}
+	if expiringStore.isExpired(element.Value.(*expiryEntry)) {
+		expiringStore.evict(element)
+		return models.PageInfo{}, false
+	}
+
+	expiringStore.lru.MoveToFront(element)
+	return expiringStore.inner.Retrieve(id)
+}
+
+// This is to replace page info stored under the given ID if it has not expired yet.
+// Updating an entry does not extend its lifetime.
+func (expiringStore *ExpiringStore) Update(id string, info models.PageInfo) (bool, error) {
+	expiringStore.Lock()
+	defer expiringStore.Unlock()
+
+	element, exists := expiringStore.entries[id]
+	if !exists {
+		return false, nil
+	}
+	entry := element.Value.(*expiryEntry)
+	if expiringStore.isExpired(entry) {
+		expiringStore.evict(element)
+		return false, nil
+	}
+
+	updated, err := expiringStore.inner.Update(id, info)
+	if err != nil || !updated {
+		return updated, err
+	}
+
+	size := entrySize(info)
+	expiringStore.usedBytes += size - entry.size
+	entry.size = size
+	expiringStore.lru.MoveToFront(element)
+	expiringStore.enforceBounds()
+	return true, nil
+}
+
+// This is to delete page info stored under the given ID.
+func (expiringStore *ExpiringStore) Delete(id string) (bool, error) {
+	expiringStore.Lock()
+	defer expiringStore.Unlock()
+
+	deleted, err := expiringStore.inner.Delete(id)
+	if err != nil {
+		// Entry is still in the backend, so it is kept tracked to expire later.
+		return deleted, err
+	}
+	if element, exists := expiringStore.entries[id]; exists {
+		expiringStore.untrack(element)
+	}
+	return deleted, nil
+}
+
+// This is to list IDs of all stored page info which have not expired yet.
+func (expiringStore *ExpiringStore) List() []string {
+	expiringStore.Lock()
+	defer expiringStore.Unlock()
+
+	ids := make([]string, 0, len(expiringStore.entries))
+	for id, element := range expiringStore.entries {
+		if !expiringStore.isExpired(element.Value.(*expiryEntry)) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// This is to check whether the given ID was stored once but expired or got evicted since.
+func (expiringStore *ExpiringStore) IsExpired(id string) bool {
+	expiringStore.Lock()
+	defer expiringStore.Unlock()
+
+	if element, exists := expiringStore.entries[id]; exists {
+		if !expiringStore.isExpired(element.Value.(*expiryEntry)) {
+			return false
+		}
+		expiringStore.evict(element)
+	}
+	_, expired := expiringStore.expired[id]
+	return expired
+}
+
+// This is to remove all expired entries and stale expired IDs.
+// Returns the number of removed entries.
+func (expiringStore *ExpiringStore) RemoveExpired() int {
+	expiringStore.Lock()
+	defer expiringStore.Unlock()
+
+	removed := 0
+	for element := expiringStore.lru.Back(); element != nil; {
+		previous := element.Prev()
+		if expiringStore.isExpired(element.Value.(*expiryEntry)) {
+			expiringStore.evict(element)
+			removed++
+		}
+		element = previous
+	}
+
+	tombstoneTTL := expiringStore.ttl
+	if tombstoneTTL <= 0 {
+		tombstoneTTL = defaultTombstoneTTL
+	}
+	for id, expiredAt := range expiringStore.expired {
+		if expiringStore.now().Sub(expiredAt) > tombstoneTTL {
+			delete(expiringStore.expired, id)
+		}
+	}
+	return removed
+}
+
+// This is to check whether the given entry has outlived its TTL.
+func (expiringStore *ExpiringStore) isExpired(entry *expiryEntry) bool {
+	return expiringStore.ttl > 0 && !expiringStore.now().Before(entry.expiresAt)
+}
+
+// This is to start tracking a newly stored entry as the most recently used one.
+func (expiringStore *ExpiringStore) track(id string, info models.PageInfo) {
+	entry := &expiryEntry{
+		id:        id,
+		size:      entrySize(info),
+		expiresAt: expiringStore.now().Add(expiringStore.ttl),
+	}
+	expiringStore.entries[id] = expiringStore.lru.PushFront(entry)
+	expiringStore.usedBytes += entry.size
+}
+
+// This is to stop tracking an entry which is already tracked.
+func (expiringStore *ExpiringStore) forget(id string) {
+	if element, exists := expiringStore.entries[id]; exists {
+		expiringStore.untrack(element)
+	}
+	delete(expiringStore.expired, id)
+}
+
+// This is to stop tracking the given entry.
+func (expiringStore *ExpiringStore) untrack(element *list.Element) {
+	entry := expiringStore.lru.Remove(element).(*expiryEntry)
+	delete(expiringStore.entries, entry.id)
+	expiringStore.usedBytes -= entry.size
+}
+
+// This is to remove the given entry from the backend and remember its ID as expired.
+func (expiringStore *ExpiringStore) evict(element *list.Element) {
+	id := element.Value.(*expiryEntry).id
+	expiringStore.untrack(element)
+	expiringStore.expired[id] = expiringStore.now()
+
+	if _, err := expiringStore.inner.Delete(id); err != nil {
+		logger.Error(err)
+	}
+}
+
+// This is to evict least recently used entries until the store fits into its bounds.
+// The most recently used entry is always kept even if it alone exceeds the byte budget.
+func (expiringStore *ExpiringStore) enforceBounds() {
+	for expiringStore.lru.Len() > 1 {
+		overCount := expiringStore.maxEntries > 0 && expiringStore.lru.Len() > expiringStore.maxEntries
+		overBytes := expiringStore.maxBytes > 0 && expiringStore.usedBytes > expiringStore.maxBytes
+		if !overCount && !overBytes {
+			return
+		}
+		expiringStore.evict(expiringStore.lru.Back())
+	}
+}
+
+// This is to estimate the memory footprint of the given page info.
+func entrySize(info models.PageInfo) int64 {
+	encoded, err := json.Marshal(info)
+	if err != nil {
+		return 0
+	}
+	return int64(len(encoded))
+}
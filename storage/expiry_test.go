@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"scraper/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This is to build an expiring store with a controllable clock.
+func newTestExpiringStore(ttl time.Duration, maxEntries int,
+	maxBytes int64) (*ExpiringStore, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiringStore := NewExpiringStore(NewMemoryStore(), ttl, maxEntries, maxBytes)
+	expiringStore.now = func() time.Time { return now }
+	return expiringStore, &now
+}
+
+func TestExpiringStore_TTL(test_type *testing.T) {
+	expiringStore, now := newTestExpiringStore(time.Minute, 0, 0)
+
+	assert.NoError(test_type, expiringStore.Store("id-1", models.PageInfo{Title: "Test Page"}))
+
+	*now = now.Add(30 * time.Second)
+	_, exists := expiringStore.Retrieve("id-1")
+	assert.True(test_type, exists, "Entry should be retrievable before its TTL")
+	assert.False(test_type, expiringStore.IsExpired("id-1"), "Live entry should not be expired")
+
+	*now = now.Add(time.Minute)
+	_, exists = expiringStore.Retrieve("id-1")
+	assert.False(test_type, exists, "Entry should not be retrievable after its TTL")
+	assert.True(test_type, expiringStore.IsExpired("id-1"), "Entry should be reported as expired")
+	assert.False(test_type, expiringStore.IsExpired("unknown-id"),
+		"Unknown ID should not be reported as expired")
+
+	_, exists = expiringStore.inner.Retrieve("id-1")
+	assert.False(test_type, exists, "Expired entry should be removed from the backend")
+}
+
+func TestExpiringStore_RemoveExpired(test_type *testing.T) {
+	expiringStore, now := newTestExpiringStore(time.Minute, 0, 0)
+
+	assert.NoError(test_type, expiringStore.Store("id-1", models.PageInfo{}))
+	*now = now.Add(30 * time.Second)
+	assert.NoError(test_type, expiringStore.Store("id-2", models.PageInfo{}))
+
+	*now = now.Add(45 * time.Second)
+	assert.Equal(test_type, 1, expiringStore.RemoveExpired(), "Only one entry should expire")
+	assert.Equal(test_type, []string{"id-2"}, expiringStore.List())
+	assert.True(test_type, expiringStore.IsExpired("id-1"))
+
+	// Expired IDs are forgotten after another TTL.
+	*now = now.Add(2 * time.Minute)
+	expiringStore.RemoveExpired()
+	assert.False(test_type, expiringStore.IsExpired("id-1"),
+		"Stale expired ID should be forgotten")
+}
+
+func TestExpiringStore_MaxEntries(test_type *testing.T) {
+	expiringStore, _ := newTestExpiringStore(0, 2, 0)
+
+	assert.NoError(test_type, expiringStore.Store("id-1", models.PageInfo{}))
+	assert.NoError(test_type, expiringStore.Store("id-2", models.PageInfo{}))
+
+	// Touch the first entry so the second one becomes the least recently used.
+	_, exists := expiringStore.Retrieve("id-1")
+	assert.True(test_type, exists)
+
+	assert.NoError(test_type, expiringStore.Store("id-3", models.PageInfo{}))
+
+	assert.ElementsMatch(test_type, []string{"id-1", "id-3"}, expiringStore.List())
+	assert.True(test_type, expiringStore.IsExpired("id-2"),
+		"Least recently used entry should be evicted")
+}
+
+func TestExpiringStore_MaxBytes(test_type *testing.T) {
+	large := models.PageInfo{Title: strings.Repeat("a", 100)}
+	expiringStore, _ := newTestExpiringStore(0, 0, 2*entrySize(large)+10)
+
+	assert.NoError(test_type, expiringStore.Store("id-1", large))
+	assert.NoError(test_type, expiringStore.Store("id-2", large))
+	assert.NoError(test_type, expiringStore.Store("id-3", large))
+
+	assert.ElementsMatch(test_type, []string{"id-2", "id-3"}, expiringStore.List())
+	assert.LessOrEqual(test_type, expiringStore.usedBytes, expiringStore.maxBytes)
+
+	// Growing an entry through an update evicts others to stay within the budget.
+	updated, err := expiringStore.Update("id-3",
+		models.PageInfo{Title: strings.Repeat("a", 150)})
+	assert.NoError(test_type, err)
+	assert.True(test_type, updated)
+	assert.Equal(test_type, []string{"id-3"}, expiringStore.List())
+}
+
+func TestExpiringStore_SeedsFromBackend(test_type *testing.T) {
+	backend := NewMemoryStore()
+	assert.NoError(test_type, backend.Store("id-1", models.PageInfo{}))
+	assert.NoError(test_type, backend.Store("id-2", models.PageInfo{}))
+
+	expiringStore := NewExpiringStore(backend, time.Minute, 1, 0)
+
+	assert.Len(test_type, expiringStore.List(), 1,
+		"Entries loaded from the backend should respect the bounds")
+	assert.Len(test_type, backend.List(), 1, "Evicted entries should be removed from the backend")
+}
+
+func TestExpiringStore_FailedDelete(test_type *testing.T) {
+	backend := failingStore{NewMemoryStore()}
+	assert.NoError(test_type, backend.MemoryStore.Store("id-1", models.PageInfo{}))
+	expiringStore := NewExpiringStore(backend, time.Minute, 0, 0)
+
+	deleted, err := expiringStore.Delete("id-1")
+	assert.Error(test_type, err)
+	assert.False(test_type, deleted)
+	assert.Equal(test_type, []string{"id-1"}, expiringStore.List())
+	assert.Contains(test_type, expiringStore.entries, "id-1",
+		"Entry which failed to be deleted should still be tracked to expire")
+}
+
+func TestStartJanitor(test_type *testing.T) {
+	previousStore := currentStore()
+	defer SetStore(previousStore)
+
+	expiringStore, now := newTestExpiringStore(time.Minute, 0, 0)
+	SetStore(expiringStore)
+	assert.NoError(test_type, expiringStore.Store("id-1", models.PageInfo{}))
+	*now = now.Add(2 * time.Minute)
+
+	stop := StartJanitor(time.Millisecond)
+	defer stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(expiringStore.inner.List()) > 0 {
+		if time.Now().After(deadline) {
+			test_type.Fatal("Janitor did not remove the expired entry in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStartJanitor_Disabled(test_type *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		var stop func()
+		assert.NotPanics(test_type, func() { stop = StartJanitor(interval) },
+			"Janitor should be disabled rather than panic for a non-positive interval")
+		assert.NotPanics(test_type, stop)
+	}
+}
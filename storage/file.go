@@ -0,0 +1,273 @@
+// This is a file backed storage backend.
+// All changes are appended to a single log file as JSON lines, so stored data
+// survives restarts. The log is replayed into memory when the store is opened, and
+// rewritten with only the live records whenever it holds twice as many records as
+// there is live data, either on open or after a change.
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"scraper/logger"
+	"scraper/models"
+	"sync"
+)
+
+const (
+	fileOperationPut    = "put"
+	fileOperationDelete = "delete"
+)
+
+// Single line of the storage log file.
+type fileRecord struct {
+	Operation string           `json:"op"`
+	ID        string           `json:"id"`
+	Info      *models.PageInfo `json:"info,omitempty"`
+}
+
+type FileStore struct {
+	sync.RWMutex
+	path    string
+	file    *os.File
+	records int
+	data    map[string]models.PageInfo
+}
+
+// This is to open (or create) the storage file in the given path.
+func NewFileStore(path string) (*FileStore, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	fileStore := &FileStore{path: path, data: make(map[string]models.PageInfo)}
+	if err := fileStore.load(); err != nil {
+		return nil, err
+	}
+	if fileStore.isStale() {
+		if err := fileStore.compact(); err != nil {
+			return nil, err
+		}
+	}
+	if err := fileStore.open(); err != nil {
+		return nil, err
+	}
+	return fileStore, nil
+}
+
+// This is to open the storage file for appending records.
+func (fileStore *FileStore) open() error {
+	file, err := os.OpenFile(fileStore.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	fileStore.file = file
+	return nil
+}
+
+// This is to store page info under the given ID.
+func (fileStore *FileStore) Store(id string, info models.PageInfo) error {
+	fileStore.Lock()
+	defer fileStore.Unlock()
+
+	if _, exists := fileStore.data[id]; exists {
+		return ErrDuplicateID
+	}
+	record := fileRecord{Operation: fileOperationPut, ID: id, Info: &info}
+	if err := fileStore.append(record); err != nil {
+		return err
+	}
+	fileStore.data[id] = info
+	fileStore.compactIfStale()
+	return nil
+}
+
+// This is to retrieve page info by ID.
+func (fileStore *FileStore) Retrieve(id string) (models.PageInfo, bool) {
+	fileStore.RLock()
+	defer fileStore.RUnlock()
+
+	info, exists := fileStore.data[id]
+	return info, exists
+}
+
+// This is to replace existing page info stored under the given ID.
+func (fileStore *FileStore) Update(id string, info models.PageInfo) (bool, error) {
+	fileStore.Lock()
+	defer fileStore.Unlock()
+
+	if _, exists := fileStore.data[id]; !exists {
+		return false, nil
+	}
+	record := fileRecord{Operation: fileOperationPut, ID: id, Info: &info}
+	if err := fileStore.append(record); err != nil {
+		return false, err
+	}
+	fileStore.data[id] = info
+	fileStore.compactIfStale()
+	return true, nil
+}
+
+// This is to delete page info stored under the given ID.
+func (fileStore *FileStore) Delete(id string) (bool, error) {
+	fileStore.Lock()
+	defer fileStore.Unlock()
+
+	if _, exists := fileStore.data[id]; !exists {
+		return false, nil
+	}
+	if err := fileStore.append(fileRecord{Operation: fileOperationDelete, ID: id}); err != nil {
+		return false, err
+	}
+	delete(fileStore.data, id)
+	fileStore.compactIfStale()
+	return true, nil
+}
+
+// This is to list IDs of all stored page info.
+func (fileStore *FileStore) List() []string {
+	fileStore.RLock()
+	defer fileStore.RUnlock()
+
+	ids := make([]string, 0, len(fileStore.data))
+	for id := range fileStore.data {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// This is to close the underlying storage file.
+func (fileStore *FileStore) Close() error {
+	fileStore.Lock()
+	defer fileStore.Unlock()
+
+	return fileStore.file.Close()
+}
+
+// This is to write a single record at the end of the storage file.
+// Records are written before the data in memory is changed, so a change which fails to be
+// written is not applied at all.
+func (fileStore *FileStore) append(record fileRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := fileStore.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write to storage file %s: %w", fileStore.path, err)
+	}
+	fileStore.records++
+	return nil
+}
+
+// This is to compact the storage file once it holds enough stale records.
+// Storage file is reopened as it is replaced by the compacted one. The change itself is
+// already written, so a failed compaction is only logged and tried again on a later change.
+// Failing to reopen the file is logged too, and makes later changes fail to be written.
+func (fileStore *FileStore) compactIfStale() {
+	if !fileStore.isStale() {
+		return
+	}
+
+	if err := fileStore.file.Close(); err != nil {
+		logger.Error(err)
+	}
+	if err := fileStore.compact(); err != nil {
+		logger.Error(fmt.Sprintf("Failed to compact storage file %s: %v", fileStore.path, err))
+	}
+	if err := fileStore.open(); err != nil {
+		logger.Error(fmt.Sprintf("Failed to reopen storage file %s: %v", fileStore.path, err))
+	}
+}
+
+// This is to check if the storage file holds enough stale records to be compacted.
+func (fileStore *FileStore) isStale() bool {
+	return fileStore.records > 2*len(fileStore.data)
+}
+
+// This is to replay the storage file records into memory.
+// Every record ends with a new line, so a last line without one is what a crash while
+// appending leaves behind. Such a line is dropped and cut off the file.
+func (fileStore *FileStore) load() error {
+	file, err := os.Open(fileStore.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) == 0 {
+				return nil
+			}
+			logger.Error(fmt.Sprintf("Dropping partially written last record of storage file %s",
+				fileStore.path))
+			return os.Truncate(fileStore.path, offset)
+		}
+		if err != nil {
+			return err
+		}
+
+		var record fileRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("corrupted storage file %s: %w", fileStore.path, err)
+		}
+
+		switch record.Operation {
+		case fileOperationPut:
+			if record.Info != nil {
+				fileStore.data[record.ID] = *record.Info
+			}
+		case fileOperationDelete:
+			delete(fileStore.data, record.ID)
+		}
+		fileStore.records++
+		offset += int64(len(line))
+	}
+}
+
+// This is to rewrite the storage file with only the live records.
+func (fileStore *FileStore) compact() error {
+	tempPath := fileStore.path + ".tmp"
+	temp, err := os.Create(tempPath)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(temp)
+	for id, info := range fileStore.data {
+		line, err := json.Marshal(fileRecord{Operation: fileOperationPut, ID: id, Info: &info})
+		if err != nil {
+			temp.Close()
+			return err
+		}
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			temp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempPath, fileStore.path); err != nil {
+		return err
+	}
+	fileStore.records = len(fileStore.data)
+	return nil
+}
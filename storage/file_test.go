@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"scraper/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(test_type *testing.T) {
+	path := filepath.Join(test_type.TempDir(), "scraper.db")
+
+	fileStore, err := NewFileStore(path)
+	assert.NoError(test_type, err)
+
+	assert.NoError(test_type, fileStore.Store("id-1", models.PageInfo{Title: "First Page"}))
+	assert.NoError(test_type, fileStore.Store("id-2", models.PageInfo{Title: "Second Page"}))
+
+	updated, err := fileStore.Update("id-1", models.PageInfo{Title: "Updated Page"})
+	assert.NoError(test_type, err)
+	assert.True(test_type, updated, "Stored PageInfo should be updatable")
+
+	deleted, err := fileStore.Delete("id-2")
+	assert.NoError(test_type, err)
+	assert.True(test_type, deleted, "Stored PageInfo should be deletable")
+	assert.NoError(test_type, fileStore.Close())
+
+	// Reopen the store to make sure data survives a restart.
+	reopened, err := NewFileStore(path)
+	assert.NoError(test_type, err)
+	defer reopened.Close()
+
+	info, exists := reopened.Retrieve("id-1")
+	assert.True(test_type, exists, "Stored PageInfo should survive a restart")
+	assert.Equal(test_type, "Updated Page", info.Title, "Latest update should survive a restart")
+
+	_, exists = reopened.Retrieve("id-2")
+	assert.False(test_type, exists, "Deleted PageInfo should stay deleted after a restart")
+	assert.Equal(test_type, []string{"id-1"}, reopened.List())
+
+	// Stale records should be compacted away on reopen.
+	content, err := os.ReadFile(path)
+	assert.NoError(test_type, err)
+	assert.Equal(test_type, 1, strings.Count(string(content), "\n"),
+		"Storage file should be compacted to live records")
+}
+
+func TestFileStore_Corrupted(test_type *testing.T) {
+	path := filepath.Join(test_type.TempDir(), "scraper.db")
+	assert.NoError(test_type, os.WriteFile(path, []byte("not json\n"), 0o644))
+
+	_, err := NewFileStore(path)
+	assert.Error(test_type, err, "Corrupted storage file should not be opened")
+}
+
+func TestFileStore_FailedWrite(test_type *testing.T) {
+	path := filepath.Join(test_type.TempDir(), "scraper.db")
+
+	fileStore, err := NewFileStore(path)
+	assert.NoError(test_type, err)
+	assert.NoError(test_type, fileStore.Store("id-1", models.PageInfo{Title: "First Page"}))
+
+	// Swap the storage file for a read only one, so every write fails.
+	assert.NoError(test_type, fileStore.file.Close())
+	fileStore.file, err = os.Open(path)
+	assert.NoError(test_type, err)
+	defer fileStore.Close()
+
+	assert.Error(test_type, fileStore.Store("id-2", models.PageInfo{Title: "Second Page"}))
+	_, exists := fileStore.Retrieve("id-2")
+	assert.False(test_type, exists, "Failed store should not be kept in memory")
+
+	updated, err := fileStore.Update("id-1", models.PageInfo{Title: "Updated Page"})
+	assert.Error(test_type, err)
+	assert.False(test_type, updated)
+	info, _ := fileStore.Retrieve("id-1")
+	assert.Equal(test_type, "First Page", info.Title, "Failed update should not be kept in memory")
+
+	deleted, err := fileStore.Delete("id-1")
+	assert.Error(test_type, err)
+	assert.False(test_type, deleted)
+	_, exists = fileStore.Retrieve("id-1")
+	assert.True(test_type, exists, "Failed delete should not be kept in memory")
+}
+
+func TestFileStore_CompactsWhileRunning(test_type *testing.T) {
+	path := filepath.Join(test_type.TempDir(), "scraper.db")
+	fileStore, err := NewFileStore(path)
+	assert.NoError(test_type, err)
+	defer fileStore.Close()
+
+	assert.NoError(test_type, fileStore.Store("id-1", models.PageInfo{Title: "Page"}))
+	for i := 0; i < 10; i++ {
+		_, err := fileStore.Update("id-1", models.PageInfo{Title: fmt.Sprintf("Page %d", i)})
+		assert.NoError(test_type, err)
+	}
+
+	content, err := os.ReadFile(path)
+	assert.NoError(test_type, err)
+	assert.LessOrEqual(test_type, strings.Count(string(content), "\n"), 2,
+		"Storage file should be compacted without a restart")
+
+	// Changes keep being appended to the compacted file.
+	assert.NoError(test_type, fileStore.Store("id-2", models.PageInfo{Title: "Second Page"}))
+	reopened, err := NewFileStore(path)
+	assert.NoError(test_type, err)
+	defer reopened.Close()
+	info, _ := reopened.Retrieve("id-1")
+	assert.Equal(test_type, "Page 9", info.Title)
+	_, exists := reopened.Retrieve("id-2")
+	assert.True(test_type, exists)
+}
+
+func TestFileStore_PartialLastRecord(test_type *testing.T) {
+	path := filepath.Join(test_type.TempDir(), "scraper.db")
+	content := `{"op":"put","id":"id-1","info":{"title":"First Page"}}` + "\n" +
+		`{"op":"put","id":"id-2","info":{"tit`
+	assert.NoError(test_type, os.WriteFile(path, []byte(content), 0o644))
+
+	fileStore, err := NewFileStore(path)
+	assert.NoError(test_type, err, "Partially written last record should not fail the store")
+	assert.Equal(test_type, []string{"id-1"}, fileStore.List())
+
+	// New records should not be appended to the partial one.
+	assert.NoError(test_type, fileStore.Store("id-3", models.PageInfo{Title: "Third Page"}))
+	assert.NoError(test_type, fileStore.Close())
+
+	reopened, err := NewFileStore(path)
+	assert.NoError(test_type, err)
+	defer reopened.Close()
+	assert.ElementsMatch(test_type, []string{"id-1", "id-3"}, reopened.List())
+}
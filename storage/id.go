@@ -0,0 +1,117 @@
+// This is to generate unique IDs for stored page info.
+// IDs are generated from a cryptographically secure random source so they can not be
+// guessed, and the ID format can be selected via config.
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"scraper/config"
+	"time"
+)
+
+// Supported request ID formats.
+const (
+	IDFormatRandom = "random"
+	IDFormatUUIDv7 = "uuidv7"
+	IDFormatULID   = "ulid"
+)
+
+const (
+	// Length of a random ID, which gives ~130 bits of entropy.
+	randomIDLength = 22
+	// Maximum number of attempts to find an unused ID upon storing data.
+	maxIDAttempts = 5
+)
+
+var (
+	// Returned by storage backends when data is already stored under the given ID.
+	ErrDuplicateID = errors.New("ID is already in use")
+	// Returned when every generated ID is already in use.
+	ErrNoUnusedID = errors.New("no unused ID could be generated")
+)
+
+// This is to generate IDs upon storing data. Replaced in tests to force collisions.
+var generateID = GenerateID
+
+// This is to generate the random unique ID in the configured format.
+func GenerateID() string {
+	return generateIDWithFormat(config.GetRequestIDFormat())
+}
+
+// This is to generate the random unique ID in the given format.
+func generateIDWithFormat(format string) string {
+	switch format {
+	case IDFormatUUIDv7:
+		return uuidV7(time.Now())
+	case IDFormatULID:
+		return ulid(time.Now())
+	default:
+		return randomString(randomIDLength)
+	}
+}
+
+// This is to generate a random string to be used as the random unique ID.
+func randomString(size int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	result := make([]byte, size)
+	lettersCount := big.NewInt(int64(len(letters)))
+
+	for i := range result {
+		index, err := rand.Int(rand.Reader, lettersCount)
+		if err != nil {
+			panic(fmt.Sprintf("failed to read from the secure random source: %v", err))
+		}
+		result[i] = letters[index.Int64()]
+	}
+	return string(result)
+}
+
+// This is to generate a time ordered UUID (version 7) as per RFC 9562.
+func uuidV7(now time.Time) string {
+	var uuid [16]byte
+	readRandom(uuid[6:])
+
+	// First 48 bits hold the unix timestamp in milliseconds.
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(now.UnixMilli()))
+	copy(uuid[:6], timestamp[2:])
+
+	uuid[6] = (uuid[6] & 0x0f) | 0x70 // version 7
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // RFC 9562 variant
+
+	encoded := hex.EncodeToString(uuid[:])
+	return encoded[0:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" +
+		encoded[16:20] + "-" + encoded[20:32]
+}
+
+// This is to generate a lexicographically sortable ULID.
+func ulid(now time.Time) string {
+	const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ" // Crockford's base32
+
+	// First 48 bits hold the unix timestamp in milliseconds, rest 80 bits are random.
+	var value [16]byte
+	binary.BigEndian.PutUint64(value[:8], uint64(now.UnixMilli())<<16)
+	readRandom(value[6:])
+
+	// 128 bits are encoded into 26 characters, 5 bits per character.
+	number := new(big.Int).SetBytes(value[:])
+	mask := big.NewInt(0x1f)
+	result := make([]byte, 26)
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = alphabet[new(big.Int).And(number, mask).Int64()]
+		number.Rsh(number, 5)
+	}
+	return string(result)
+}
+
+// This is to fill the given buffer from the secure random source.
+func readRandom(buffer []byte) {
+	if _, err := rand.Read(buffer); err != nil {
+		panic(fmt.Sprintf("failed to read from the secure random source: %v", err))
+	}
+}
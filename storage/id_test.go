@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"scraper/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateID(test_type *testing.T) {
+	// Call the private function indirectly by calling StorePageInfo
+	pageInfo := &models.PageInfo{Title: "Test Page"}
+	id, err := StorePageInfo(pageInfo)
+	assert.NoError(test_type, err)
+
+	// Assert that the ID follows the expected format
+	assert.Regexp(test_type, `^[a-zA-Z0-9]{22}$`, id,
+		"Generated ID should follow the correct format")
+}
+
+func TestGenerateIDWithFormat(test_type *testing.T) {
+	tests := []struct {
+		format  string
+		pattern string
+	}{
+		{format: IDFormatRandom, pattern: `^[a-zA-Z0-9]{22}$`},
+		{format: IDFormatUUIDv7,
+			pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{format: IDFormatULID, pattern: `^[0-9A-HJKMNP-TV-Z]{26}$`},
+		{format: "unknown", pattern: `^[a-zA-Z0-9]{22}$`},
+	}
+
+	for _, test_data := range tests {
+		test_type.Run(test_data.format, func(test_type *testing.T) {
+			id := generateIDWithFormat(test_data.format)
+			assert.Regexp(test_type, test_data.pattern, id,
+				"Generated ID should follow the correct format")
+			assert.NotEqual(test_type, id, generateIDWithFormat(test_data.format),
+				"Generated IDs should be unique")
+		})
+	}
+}
+
+func TestTimeOrderedIDs(test_type *testing.T) {
+	earlier := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Millisecond)
+
+	assert.Less(test_type, uuidV7(earlier), uuidV7(later), "UUIDv7 should sort by time")
+	assert.Less(test_type, ulid(earlier), ulid(later), "ULID should sort by time")
+	assert.Equal(test_type, "01JGFJJZ00", ulid(earlier)[:10],
+		"ULID should start with the encoded timestamp")
+	assert.Equal(test_type, "01941f29-7c00", uuidV7(earlier)[:13],
+		"UUIDv7 should start with the encoded timestamp")
+}
+
+func TestRandomString(test_type *testing.T) {
+	randomStr := randomString(10)
+
+	// Assert that the random string is of the correct length
+	assert.Equal(test_type, 10, len(randomStr), "Random string should have the correct length")
+
+	// Check that the string only contains valid characters
+	for _, char := range randomStr {
+		assert.Contains(test_type,
+			"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789", string(char),
+			"Random string should only contain valid characters")
+	}
+}
+
+func TestStorePageInfo_ConcurrentUniqueIDs(test_type *testing.T) {
+	const workers = 50
+	const perWorker = 100
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ids := make(map[string]struct{}, workers*perWorker)
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				id, err := StorePageInfo(&models.PageInfo{Title: fmt.Sprintf("Page %d", i)})
+				assert.NoError(test_type, err)
+
+				mu.Lock()
+				ids[id] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(test_type, ids, workers*perWorker, "Concurrently generated IDs should be unique")
+}
+
+func TestStorePageInfo_Collision(test_type *testing.T) {
+	previousStore := currentStore()
+	backend := NewMemoryStore()
+	SetStore(backend)
+	defer SetStore(previousStore)
+
+	previousGenerateID := generateID
+	defer func() { generateID = previousGenerateID }()
+
+	// Occupy an ID and make sure storing under it again is rejected.
+	assert.NoError(test_type, backend.Store("taken-id", models.PageInfo{Title: "First"}))
+	assert.ErrorIs(test_type, backend.Store("taken-id", models.PageInfo{}), ErrDuplicateID)
+
+	info, _ := backend.Retrieve("taken-id")
+	assert.Equal(test_type, "First", info.Title, "Colliding insert should not overwrite data")
+
+	// A colliding ID is retried with a new one.
+	ids := []string{"taken-id", "free-id"}
+	generateID = func() string {
+		id := ids[0]
+		ids = ids[1:]
+		return id
+	}
+	id, err := StorePageInfo(&models.PageInfo{Title: "Second"})
+	assert.NoError(test_type, err)
+	assert.Equal(test_type, "free-id", id)
+	assert.Len(test_type, backend.List(), 2)
+
+	// Once all attempts collide, no ID is handed out.
+	attempts := 0
+	generateID = func() string {
+		attempts++
+		return "taken-id"
+	}
+	id, err = StorePageInfo(&models.PageInfo{Title: "Third"})
+	assert.ErrorIs(test_type, err, ErrNoUnusedID)
+	assert.Empty(test_type, id)
+	assert.Equal(test_type, maxIDAttempts, attempts)
+	info, _ = backend.Retrieve("taken-id")
+	assert.Equal(test_type, "First", info.Title, "Exhausted attempts should not overwrite data")
+}
+
+// Store backend which fails to store anything.
+type failingStore struct {
+	*MemoryStore
+}
+
+func (store failingStore) Store(id string, info models.PageInfo) error {
+	return errors.New("disk is full")
+}
+
+func (store failingStore) Delete(id string) (bool, error) {
+	return false, errors.New("disk is full")
+}
+
+func TestStorePageInfo_StoreError(test_type *testing.T) {
+	previousStore := currentStore()
+	SetStore(failingStore{NewMemoryStore()})
+	defer SetStore(previousStore)
+
+	id, err := StorePageInfo(&models.PageInfo{Title: "Page"})
+	assert.EqualError(test_type, err, "disk is full")
+	assert.Empty(test_type, id)
+}
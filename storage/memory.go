@@ -1,54 +1,74 @@
-// This is a simple in-memory storage to store page info to support for pagination.
-// Each store page info is mapped to a random unique ID which generated upon storing data.
-// To retrieve stored page info need to provide the ID generator upon storing data.
-// This simple storage supports only store and retrieve operations as of now.
+// This is a simple in-memory storage backend.
+// Stored data lives only as long as the process, so it is lost on restart.
 package storage
 
 import (
-	"math/rand"
 	"scraper/models"
 	"sync"
-	"time"
 )
 
-var storage = struct {
+type MemoryStore struct {
 	sync.RWMutex
 	data map[string]models.PageInfo
-}{data: make(map[string]models.PageInfo)}
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string]models.PageInfo)}
+}
 
-// This is to store page info.
-func StorePageInfo(info *models.PageInfo) string {
-	storage.Lock()
-	defer storage.Unlock()
+// This is to store page info under the given ID.
+func (memory *MemoryStore) Store(id string, info models.PageInfo) error {
+	memory.Lock()
+	defer memory.Unlock()
 
-	id := generateID()
-	storage.data[id] = *info
-	return id
+	if _, exists := memory.data[id]; exists {
+		return ErrDuplicateID
+	}
+	memory.data[id] = info
+	return nil
 }
 
-// This is to retrieve page info by unique ID.
-func RetrievePageInfo(id string) (*models.PageInfo, bool) {
-	storage.RLock()
-	defer storage.RUnlock()
+// This is to retrieve page info by ID.
+func (memory *MemoryStore) Retrieve(id string) (models.PageInfo, bool) {
+	memory.RLock()
+	defer memory.RUnlock()
 
-	info, exists := storage.data[id]
-	return &info, exists
+	info, exists := memory.data[id]
+	return info, exists
 }
 
-// This is to generate the random unique ID.
-func generateID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(8)
+// This is to replace existing page info stored under the given ID.
+func (memory *MemoryStore) Update(id string, info models.PageInfo) (bool, error) {
+	memory.Lock()
+	defer memory.Unlock()
+
+	if _, exists := memory.data[id]; !exists {
+		return false, nil
+	}
+	memory.data[id] = info
+	return true, nil
 }
 
-// This is to generate a random string to append into the random unique ID.
-func randomString(size int) string {
-	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	result := make([]byte, size)
+// This is to delete page info stored under the given ID.
+func (memory *MemoryStore) Delete(id string) (bool, error) {
+	memory.Lock()
+	defer memory.Unlock()
+
+	if _, exists := memory.data[id]; !exists {
+		return false, nil
+	}
+	delete(memory.data, id)
+	return true, nil
+}
 
-	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+// This is to list IDs of all stored page info.
+func (memory *MemoryStore) List() []string {
+	memory.RLock()
+	defer memory.RUnlock()
 
-	for i := range result {
-		result[i] = letters[random.Intn(len(letters))]
+	ids := make([]string, 0, len(memory.data))
+	for id := range memory.data {
+		ids = append(ids, id)
 	}
-	return string(result)
+	return ids
 }
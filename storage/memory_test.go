@@ -7,55 +7,30 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestStorePageInfo(test_type *testing.T) {
-	pageInfo := &models.PageInfo{
-		Title: "Test Page",
-	}
+func TestMemoryStore(test_type *testing.T) {
+	memoryStore := NewMemoryStore()
 
-	id := StorePageInfo(pageInfo)
+	err := memoryStore.Store("id-1", models.PageInfo{Title: "Test Page"})
+	assert.NoError(test_type, err)
 
-	// Ensure the ID is not empty
-	assert.NotEmpty(test_type, id, "Generated ID should not be empty")
-
-	retrievedInfo, exists := RetrievePageInfo(id)
-
-	// Assert that the PageInfo exists
+	info, exists := memoryStore.Retrieve("id-1")
 	assert.True(test_type, exists, "Stored PageInfo should be retrievable")
-	// Assert that the retrieved info matches the original
-	assert.Equal(test_type, pageInfo.Title, retrievedInfo.Title, "Title should match")
-}
+	assert.Equal(test_type, "Test Page", info.Title, "Title should match")
 
-func TestRetrievePageInfo_NotFound(test_type *testing.T) {
-	// Try retrieving a non-existent PageInfo
-	retrievedInfo, exists := RetrievePageInfo("nonexistent-id")
+	updated, err := memoryStore.Update("id-1", models.PageInfo{Title: "Updated Page"})
+	assert.NoError(test_type, err)
+	assert.True(test_type, updated, "Stored PageInfo should be updatable")
 
-	// Assert that the info does not exist
-	assert.False(test_type, exists, "Non-existent ID should not be found")
-	// Assert that the returned PageInfo is empty
-	assert.Equal(test_type, &models.PageInfo{}, retrievedInfo,
-		"Retrieved info should be an empty PageInfo for non-existent ID")
-}
-
-func TestGenerateID(test_type *testing.T) {
-	// Call the private function indirectly by calling StorePageInfo
-	pageInfo := &models.PageInfo{Title: "Test Page"}
-	id := StorePageInfo(pageInfo)
-
-	// Assert that the ID follows the expected format
-	assert.Regexp(test_type, `^\d{14}-[a-zA-Z0-9]{8}$`, id,
-		"Generated ID should follow the correct format")
-}
+	updated, _ = memoryStore.Update("id-2", models.PageInfo{})
+	assert.False(test_type, updated, "Non-existent ID should not be updatable")
 
-func TestRandomString(test_type *testing.T) {
-	randomStr := randomString(10)
+	assert.Equal(test_type, []string{"id-1"}, memoryStore.List())
 
-	// Assert that the random string is of the correct length
-	assert.Equal(test_type, 10, len(randomStr), "Random string should have the correct length")
+	deleted, err := memoryStore.Delete("id-1")
+	assert.NoError(test_type, err)
+	assert.True(test_type, deleted, "Stored PageInfo should be deletable")
 
-	// Check that the string only contains valid characters
-	for _, char := range randomStr {
-		assert.Contains(test_type,
-			"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789", string(char),
-			"Random string should only contain valid characters")
-	}
+	_, exists = memoryStore.Retrieve("id-1")
+	assert.False(test_type, exists, "Deleted PageInfo should not be retrievable")
+	assert.Empty(test_type, memoryStore.List())
 }
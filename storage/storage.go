@@ -0,0 +1,248 @@
+// This is the storage layer used to keep scraped page info to support for pagination.
+// Each stored page info is mapped to a random unique ID which generated upon storing data.
+// To retrieve stored page info need to provide the ID generated upon storing data.
+// The actual persistence is delegated to a pluggable Store backend selected via config.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"scraper/config"
+	"scraper/logger"
+	"scraper/models"
+	"sync"
+	"time"
+)
+
+// Supported storage backends.
+const (
+	BackendMemory = "memory"
+	BackendFile   = "file"
+)
+
+// Store is the contract every storage backend has to fulfil.
+type Store interface {
+	// Store saves the page info under the given ID.
+	// Returns ErrDuplicateID if there is already page info stored under the ID.
+	Store(id string, info models.PageInfo) error
+	// Retrieve returns the page info stored under the given ID.
+	Retrieve(id string) (models.PageInfo, bool)
+	// Update replaces the page info stored under the given ID.
+	// Returns false if there is nothing stored under the ID.
+	Update(id string, info models.PageInfo) (bool, error)
+	// Delete removes the page info stored under the given ID.
+	// Returns false if there is nothing stored under the ID.
+	Delete(id string) (bool, error)
+	// List returns IDs of all stored page info.
+	List() []string
+}
+
+// Storage backend used by the package level functions.
+var storage = struct {
+	sync.RWMutex
+	store Store
+}{}
+
+// Serializes read-modify-write updates of stored page info.
+var updates sync.Mutex
+
+func init() {
+	backend, err := NewStore(config.GetStorageBackend(), config.GetStorageFilePath())
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to initialize [%s] storage: %v. FALLING BACK TO MEMORY "+
+			"STORAGE, previously stored scrape results are not available and new ones will not "+
+			"survive a restart", config.GetStorageBackend(), err))
+		backend = NewMemoryStore()
+	}
+	storage.store = NewExpiringStore(backend,
+		time.Duration(config.GetStorageEntryTTL())*time.Second,
+		config.GetStorageMaxEntries(), config.GetStorageMaxBytes())
+}
+
+// This is to create a storage backend by its name.
+func NewStore(backend, filePath string) (Store, error) {
+	switch backend {
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendFile:
+		return NewFileStore(filePath)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", backend)
+	}
+}
+
+// This is to replace the storage backend used by the package level functions.
+func SetStore(backend Store) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.store = backend
+}
+
+// This is to get the storage backend used by the package level functions.
+func currentStore() Store {
+	storage.RLock()
+	defer storage.RUnlock()
+
+	return storage.store
+}
+
+// This is to store page info.
+// A copy of the page info is stored, so later changes to it need to go through the
+// update functions. A new ID is generated if the generated one is already in use, and an
+// error is returned if the page info could not be stored under any ID.
+func StorePageInfo(info *models.PageInfo) (string, error) {
+	for attempt := 1; attempt <= maxIDAttempts; attempt++ {
+		id := generateID()
+		err := currentStore().Store(id, clonePageInfo(*info))
+		if err == nil {
+			return id, nil
+		}
+		if !errors.Is(err, ErrDuplicateID) {
+			logger.Error(err)
+			return "", err
+		}
+		logger.Error(fmt.Sprintf("Generated ID [%s] collides with a stored one", id))
+	}
+	return "", fmt.Errorf("%w after %d attempts", ErrNoUnusedID, maxIDAttempts)
+}
+
+// This is to retrieve page info by unique ID.
+// Returned page info is a copy, so changing it does not affect the stored one.
+func RetrievePageInfo(id string) (*models.PageInfo, bool) {
+	info, exists := currentStore().Retrieve(id)
+	info = clonePageInfo(info)
+	return &info, exists
+}
+
+// This is to replace the page info stored under the given unique ID.
+func UpdatePageInfo(id string, info *models.PageInfo) bool {
+	updates.Lock()
+	defer updates.Unlock()
+
+	updated, err := currentStore().Update(id, clonePageInfo(*info))
+	if err != nil {
+		logger.Error(err)
+	}
+	return updated
+}
+
+// This is to record checked statuses of URLs in the page info stored under the given unique ID.
+// Statuses are matched to stored URLs by the URL itself, and only checked ones are recorded.
+func UpdateURLStatuses(id string, statuses []models.URLStatus) bool {
+	updates.Lock()
+	defer updates.Unlock()
+
+	info, exists := currentStore().Retrieve(id)
+	if !exists {
+		return false
+	}
+
+	checked := make(map[string]models.URLStatus, len(statuses))
+	for _, status := range statuses {
+		if status.CheckedAt != nil {
+			checked[status.URL] = status
+		}
+	}
+
+	info = clonePageInfo(info)
+	for i := range info.URLs {
+		if status, found := checked[info.URLs[i].URL]; found {
+			info.URLs[i].SetCheckResult(status)
+		}
+	}
+
+	updated, err := currentStore().Update(id, info)
+	if err != nil {
+		logger.Error(err)
+	}
+	return updated
+}
+
+// This is to delete the page info stored under the given unique ID.
+func DeletePageInfo(id string) bool {
+	deleted, err := currentStore().Delete(id)
+	if err != nil {
+		logger.Error(err)
+	}
+	return deleted
+}
+
+// This is to list unique IDs of all stored page info.
+func ListPageInfoIDs() []string {
+	return currentStore().List()
+}
+
+// This is to check whether the page info stored under the given unique ID has expired.
+// Backends without expiry support never report an ID as expired.
+func IsExpired(id string) bool {
+	if expiring, ok := currentStore().(interface{ IsExpired(string) bool }); ok {
+		return expiring.IsExpired(id)
+	}
+	return false
+}
+
+// This is to remove expired page info from backends with expiry support.
+func RemoveExpiredPageInfo() int {
+	if expiring, ok := currentStore().(interface{ RemoveExpired() int }); ok {
+		return expiring.RemoveExpired()
+	}
+	return 0
+}
+
+// This is to start the janitor which periodically removes expired page info.
+// Returned function stops the janitor. The janitor is disabled if the interval is not positive.
+func StartJanitor(interval time.Duration) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if removed := RemoveExpiredPageInfo(); removed > 0 {
+					logger.Debug(fmt.Sprintf("Storage janitor removed [%d] expired entries", removed))
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// This is to copy the page info so that the copy does not share URL statuses or counts with it.
+// Other parsed details are never changed once stored, so they are shared.
+func clonePageInfo(info models.PageInfo) models.PageInfo {
+	if info.URLs != nil {
+		info.URLs = append([]models.URLStatus(nil), info.URLs...)
+	}
+	if info.HeadingCounts != nil {
+		headingCounts := make(map[string]int, len(info.HeadingCounts))
+		for heading, count := range info.HeadingCounts {
+			headingCounts[heading] = count
+		}
+		info.HeadingCounts = headingCounts
+	}
+	if info.SchemeCounts != nil {
+		schemeCounts := make(map[string]int, len(info.SchemeCounts))
+		for scheme, count := range info.SchemeCounts {
+			schemeCounts[scheme] = count
+		}
+		info.SchemeCounts = schemeCounts
+	}
+	if info.ResourceCounts != nil {
+		resourceCounts := make(map[string]int, len(info.ResourceCounts))
+		for resourceType, count := range info.ResourceCounts {
+			resourceCounts[resourceType] = count
+		}
+		info.ResourceCounts = resourceCounts
+	}
+	return info
+}
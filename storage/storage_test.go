@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"path/filepath"
+	"scraper/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorePageInfo(test_type *testing.T) {
+	pageInfo := &models.PageInfo{
+		Title: "Test Page",
+	}
+
+	id, err := StorePageInfo(pageInfo)
+	assert.NoError(test_type, err)
+
+	// Ensure the ID is not empty
+	assert.NotEmpty(test_type, id, "Generated ID should not be empty")
+
+	retrievedInfo, exists := RetrievePageInfo(id)
+
+	// Assert that the PageInfo exists
+	assert.True(test_type, exists, "Stored PageInfo should be retrievable")
+	// Assert that the retrieved info matches the original
+	assert.Equal(test_type, pageInfo.Title, retrievedInfo.Title, "Title should match")
+}
+
+func TestRetrievePageInfo_NotFound(test_type *testing.T) {
+	// Try retrieving a non-existent PageInfo
+	retrievedInfo, exists := RetrievePageInfo("nonexistent-id")
+
+	// Assert that the info does not exist
+	assert.False(test_type, exists, "Non-existent ID should not be found")
+	// Assert that the returned PageInfo is empty
+	assert.Equal(test_type, &models.PageInfo{}, retrievedInfo,
+		"Retrieved info should be an empty PageInfo for non-existent ID")
+}
+
+func TestUpdateAndDeletePageInfo(test_type *testing.T) {
+	id, err := StorePageInfo(&models.PageInfo{Title: "Test Page"})
+	assert.NoError(test_type, err)
+
+	updated := UpdatePageInfo(id, &models.PageInfo{Title: "Updated Page"})
+	assert.True(test_type, updated, "Stored PageInfo should be updatable")
+
+	retrievedInfo, _ := RetrievePageInfo(id)
+	assert.Equal(test_type, "Updated Page", retrievedInfo.Title, "Title should be updated")
+	assert.Contains(test_type, ListPageInfoIDs(), id, "Stored ID should be listed")
+
+	assert.True(test_type, DeletePageInfo(id), "Stored PageInfo should be deletable")
+	_, exists := RetrievePageInfo(id)
+	assert.False(test_type, exists, "Deleted PageInfo should not be retrievable")
+	assert.False(test_type, UpdatePageInfo(id, &models.PageInfo{}),
+		"Deleted PageInfo should not be updatable")
+}
+
+func TestNewStore(test_type *testing.T) {
+	memoryStore, err := NewStore(BackendMemory, "")
+	assert.NoError(test_type, err)
+	assert.IsType(test_type, &MemoryStore{}, memoryStore)
+
+	fileStore, err := NewStore(BackendFile, filepath.Join(test_type.TempDir(), "scraper.db"))
+	assert.NoError(test_type, err)
+	assert.IsType(test_type, &FileStore{}, fileStore)
+
+	_, err = NewStore("unknown", "")
+	assert.Error(test_type, err, "Unknown backend should not be created")
+}
+
+func TestRetrievePageInfo_ReturnsCopy(test_type *testing.T) {
+	pageInfo := &models.PageInfo{URLs: []models.URLStatus{{URL: "http://example.com"}}}
+	id, err := StorePageInfo(pageInfo)
+	assert.NoError(test_type, err)
+
+	// Changing the original page info should not affect the stored one.
+	pageInfo.URLs[0].HTTPStatus = 500
+
+	retrievedInfo, _ := RetrievePageInfo(id)
+	assert.Equal(test_type, 0, retrievedInfo.URLs[0].HTTPStatus,
+		"Stored PageInfo should not alias the original")
+
+	// Changing the retrieved page info should not affect the stored one.
+	retrievedInfo.URLs[0].HTTPStatus = 404
+
+	retrievedAgain, _ := RetrievePageInfo(id)
+	assert.Equal(test_type, 0, retrievedAgain.URLs[0].HTTPStatus,
+		"Retrieved PageInfo should not alias the stored one")
+}
+
+func TestUpdateURLStatuses(test_type *testing.T) {
+	id, err := StorePageInfo(&models.PageInfo{URLs: []models.URLStatus{
+		{URL: "http://example.com/a"},
+		{URL: "http://example.com/b"},
+	}})
+	assert.NoError(test_type, err)
+	checkedAt := time.Now()
+
+	updated := UpdateURLStatuses(id, []models.URLStatus{
+		{URL: "http://example.com/b", HTTPStatus: 404, CheckedAt: &checkedAt},
+		{URL: "http://example.com/a", HTTPStatus: 200},
+	})
+	assert.True(test_type, updated, "Stored URL statuses should be updatable")
+
+	retrievedInfo, _ := RetrievePageInfo(id)
+	assert.Nil(test_type, retrievedInfo.URLs[0].CheckedAt, "Unchecked status should be ignored")
+	assert.Equal(test_type, 0, retrievedInfo.URLs[0].HTTPStatus)
+	assert.Equal(test_type, 404, retrievedInfo.URLs[1].HTTPStatus)
+	assert.Equal(test_type, checkedAt.Unix(), retrievedInfo.URLs[1].CheckedAt.Unix())
+
+	assert.False(test_type, UpdateURLStatuses("nonexistent-id", nil),
+		"Non-existent ID should not be updatable")
+}
@@ -27,15 +27,20 @@ func min(a, b int) int {
 }
 
 // This is to build the response after a successful scraping.
+// Given query is kept on the links to the previous and next pages, so they list the same URLs.
 func BuildPageResponse(requestID string, pageNum, totalPages int, pageInfo *models.PageInfo,
-	inaccessible, start, end int) models.PageResponse {
+	inaccessible, start, end int, query string) models.PageResponse {
+	if query != "" {
+		query = "?" + query
+	}
+
 	var prevPage, nextPage *string
 	if pageNum > 1 {
-		prev := fmt.Sprintf("/scrape/%s/%d", requestID, pageNum-1)
+		prev := fmt.Sprintf("/scrape/%s/%d%s", requestID, pageNum-1, query)
 		prevPage = &prev
 	}
 	if end < len(pageInfo.URLs) {
-		next := fmt.Sprintf("/scrape/%s/%d", requestID, pageNum+1)
+		next := fmt.Sprintf("/scrape/%s/%d%s", requestID, pageNum+1, query)
 		nextPage = &next
 	}
 
@@ -50,12 +55,24 @@ func BuildPageResponse(requestID string, pageNum, totalPages int, pageInfo *mode
 		},
 		Scraped: models.ScrapedData{
 			HTMLVersion:       pageInfo.HTMLVersion,
+			RenderingMode:     pageInfo.RenderingMode,
+			IsXHTML:           pageInfo.IsXHTML,
+			Encoding:          pageInfo.Encoding,
 			Title:             pageInfo.Title,
 			Headings:          pageInfo.HeadingCounts,
+			HeadingOutline:    pageInfo.HeadingOutline,
+			HeadingIssues:     pageInfo.HeadingIssues,
 			ContainsLoginForm: pageInfo.ContainsLoginForm,
+			Forms:             pageInfo.Forms,
+			Accessibility:     pageInfo.Accessibility,
+			Content:           pageInfo.Content,
 			TotalURLs:         len(pageInfo.URLs),
 			InternalURLs:      pageInfo.InternalURLsCount,
 			ExternalURLs:      pageInfo.ExternalURLsCount,
+			SchemeCounts:      pageInfo.SchemeCounts,
+			ResourceCounts:    pageInfo.ResourceCounts,
+			Metadata:          pageInfo.Metadata,
+			StructuredData:    pageInfo.StructuredData,
 			Paginated: models.PaginatedURLs{
 				InaccessibleURLs: inaccessible,
 				URLs:             pageInfo.URLs[start:end],